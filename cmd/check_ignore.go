@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ignore"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var checkIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore <path>...",
+	Short: "Show whether paths are excluded from backups",
+	Long: `The check-ignore command reports for each path whether it would be
+included in a backup, and which pattern decided it: project config,
+.backupignore or .gitignore file with line number.
+A path inside an excluded directory is excluded by that directory's rule.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runCheckIgnore,
+}
+
+func init() {
+	rootCmd.AddCommand(checkIgnoreCmd)
+}
+
+func runCheckIgnore(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	matcher := backup.NewMatcher(currentDir, projectConfig)
+
+	for _, arg := range args {
+		absPath, absErr := filepath.Abs(arg)
+		if absErr != nil {
+			fmt.Println(ui.Error(fmt.Sprintf("%s: %v", arg, absErr)))
+			continue
+		}
+
+		relPath, relErr := filepath.Rel(currentDir, absPath)
+		if relErr != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			fmt.Println(ui.Error(fmt.Sprintf("%s: path is outside the project", arg)))
+			continue
+		}
+
+		isDir := strings.HasSuffix(arg, "/")
+		if info, statErr := os.Stat(absPath); statErr == nil {
+			isDir = info.IsDir()
+		}
+
+		match := matcher.Match(relPath, isDir)
+		displayPath := filepath.ToSlash(relPath)
+		if isDir {
+			displayPath += "/"
+		}
+
+		if match.Excluded {
+			fmt.Println(ui.Removed(fmt.Sprintf("  excluded  %-50s %s", displayPath, describeRule(match.Rule))))
+		} else {
+			fmt.Println(ui.Added(fmt.Sprintf("  included  %-50s %s", displayPath, describeRule(match.Rule))))
+		}
+	}
+
+	for _, err := range matcher.Errors() {
+		fmt.Println(ui.Warning(err.Error()))
+	}
+}
+
+func printBackupPlan(plan *backup.BackupPlan) {
+	fmt.Println(ui.Info("Dry run: no backup will be created"))
+	fmt.Println()
+
+	for _, entry := range plan.Included {
+		fmt.Println(ui.Added(fmt.Sprintf("  +  %-60s %10s %s", entry.Path, ui.FormatSize(entry.Size), describeRule(entry.Rule))))
+	}
+
+	for _, entry := range plan.Excluded {
+		entryPath := entry.Path
+		if entry.IsDir {
+			entryPath += "/"
+		}
+		fmt.Println(ui.Removed(fmt.Sprintf("  -  %-60s %10s %s", entryPath, "", describeRule(entry.Rule))))
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Label("Files", fmt.Sprintf("%d included, %d excluded entries", plan.FileCount, len(plan.Excluded))))
+	fmt.Println(ui.Label("Total size", ui.FormatSize(plan.TotalSize)))
+
+	if len(plan.Directories) > 0 {
+		fmt.Println()
+		fmt.Println(ui.LabelStyle.Render("Largest directories:"))
+		for i, dir := range plan.Directories {
+			if i == 10 {
+				break
+			}
+			fmt.Printf("  %-60s %10s  %d files\n", dir.Path+"/", ui.FormatSize(dir.Size), dir.Files)
+		}
+	}
+}
+
+func describeRule(rule *ignore.Rule) string {
+	if rule == nil {
+		return ""
+	}
+	return ui.HintStyle.Render(fmt.Sprintf("(%s:%d: %s)", rule.Source, rule.Line, rule.Pattern))
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and change project or global settings",
+	Long: `The config command manages two layers of settings.
+
+The global config (config.json in the storage root) holds defaults
+for all projects: excludes, storage mode, compression, retention
+and UI preferences. The project config (.backup-config.json) inherits
+them and can override any value; an empty value means "inherit".
+excludes replaces the global list, excludes_append adds to it.
+A non-empty project retention replaces the global retention as a whole.
+
+compression none stores zip and tar.gz entries uncompressed; tar.zst
+has no uncompressed mode and uses the fastest Zstandard level instead.
+
+Lists are comma separated. Use --global to work with the global layer.`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show effective settings and where they come from",
+	Args:  cobra.NoArgs,
+	Run:   runConfigList,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Show value of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run:   runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Change a setting (empty value resets it)",
+	Long: `Sets a value in the project config, or in the global config with --global.
+Several values are joined into a list: backup config set excludes_append "*.bak" tmp/
+An empty value ("") removes the project override so the global value is used.`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runConfigSet,
+}
+
+var configGlobal bool
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd)
+	configCmd.PersistentFlags().BoolVar(&configGlobal, "global", false, "Use global config instead of project config")
+}
+
+func runConfigList(cmd *cobra.Command, args []string) {
+	if configGlobal {
+		global, err := config.LoadGlobalConfig()
+		if err != nil {
+			fmt.Println(ui.Error(err.Error()))
+			return
+		}
+		values := global.Settings()
+		for _, value := range values {
+			fmt.Println(ui.Label(value.Key, value.Value))
+		}
+		printCompressionNote(values)
+		return
+	}
+
+	projectConfig, ok := loadProjectForConfig()
+	if !ok {
+		return
+	}
+
+	values := projectConfig.Settings()
+	for _, value := range values {
+		fmt.Printf("%s %s\n", ui.Label(value.Key, value.Value), ui.HintStyle.Render("("+value.Layer+")"))
+	}
+	printCompressionNote(values)
+}
+
+func printCompressionNote(values []config.SettingValue) {
+	effective := make(map[string]string, len(values))
+	for _, value := range values {
+		effective[value.Key] = value.Value
+	}
+	if effective["compression"] == config.CompressionNone && effective["format"] == config.FormatTarZst {
+		fmt.Println()
+		fmt.Println(ui.Hint("tar.zst has no uncompressed mode: compression none uses the fastest Zstandard level"))
+	}
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) {
+	if configGlobal {
+		global, err := config.LoadGlobalConfig()
+		if err != nil {
+			fmt.Println(ui.Error(err.Error()))
+			return
+		}
+		value, err := global.GetSetting(args[0])
+		if err != nil {
+			fmt.Println(ui.Error(err.Error()))
+			return
+		}
+		fmt.Println(value)
+		return
+	}
+
+	projectConfig, ok := loadProjectForConfig()
+	if !ok {
+		return
+	}
+
+	value, err := projectConfig.GetSetting(args[0])
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+	fmt.Println(value.Value)
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) {
+	key, value := args[0], strings.Join(args[1:], ",")
+
+	if configGlobal {
+		global, err := config.LoadGlobalConfig()
+		if err != nil {
+			fmt.Println(ui.Error(err.Error()))
+			return
+		}
+		if err := global.SetSetting(key, value); err != nil {
+			fmt.Println(ui.Error(err.Error()))
+			return
+		}
+		if err := global.Save(); err != nil {
+			fmt.Println(ui.Error(err.Error()))
+			return
+		}
+		newValue, _ := global.GetSetting(key)
+		fmt.Println(ui.Success(fmt.Sprintf("Global %s = %s", key, newValue)))
+		return
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	projectConfig, ok := loadProjectForConfig()
+	if !ok {
+		return
+	}
+
+	if err := projectConfig.SetSetting(key, value); err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+	if err := projectConfig.Save(currentDir); err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	effective, _ := projectConfig.GetSetting(key)
+	fmt.Println(ui.Success(fmt.Sprintf("%s = %s (%s)", key, effective.Value, effective.Layer)))
+}
+
+func loadProjectForConfig() (*config.ProjectConfig, bool) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return nil, false
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first, or use --global."))
+		return nil, false
+	}
+
+	return projectConfig, true
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -8,7 +9,6 @@ import (
 	"backup-tool/internal/config"
 	"backup-tool/internal/ui"
 
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
@@ -16,16 +16,71 @@ var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create new project backup",
 	Long: `The create command archives current project into ZIP file.
-Excludes standard folders (node_modules, .git, build, dist etc.)
-and saves archive to backup directory.`,
+Excludes standard folders (node_modules, .git, build, dist etc.),
+paths listed in .backupignore files (gitignore syntax)
+and saves archive to backup directory.
+
+With --format the archive can be written as zip, zip-store
+(no compression), tar.gz or tar.zst. The compression level
+is taken from the "compression" setting; for tar.zst
+"none" means the fastest Zstandard level, not raw storage.
+
+File modes, modification times and empty directories are kept.
+Symbolic links are stored as links by default; --symlinks follow
+archives the files they point to and --symlinks skip leaves them out.
+
+With --mode snapshot files are stored once by content hash
+and the backup is saved as a small snapshot manifest.
+
+Files are read and compressed by several workers at once and
+written in a fixed order, so the result does not depend on the
+number of workers. --jobs overrides the "parallelism" setting
+(default: number of CPUs). tar.gz and tar.zst are compressed as
+one stream by the writer, so for them the workers only read and
+hash files and --jobs does not speed up compression.
+
+Size, modification time, inode and hash of every file are kept in
+a file-state cache in the backup directory. Unchanged files are
+copied as already-compressed entries from the previous zip archive
+and are not re-read for snapshots. --no-cache ignores the cache
+and re-reads every file.
+
+With --if-changed (or the "skip_unchanged" project setting) a
+fingerprint of the included files is compared with the newest
+backup; if nothing changed no backup is created and the command
+exits with status 3. --if-changed=false overrides the setting.
+
+With --dry-run nothing is written: included and excluded files are
+listed with the rule responsible for each decision, followed by
+total size, file count and the largest directories.`,
 	Run: runCreate,
 }
 
-var backupName string
+const exitUnchanged = 3
+
+var (
+	backupName    string
+	backupMode    string
+	backupFormat  string
+	backupLinks   string
+	backupPinned  bool
+	backupJobs    int
+	backupNoCache bool
+	backupChanged bool
+	createDryRun  bool
+)
 
 func init() {
 	rootCmd.AddCommand(createCmd)
 	createCmd.Flags().StringVarP(&backupName, "name", "n", "", "Backup name (optional)")
+	createCmd.Flags().StringVarP(&backupMode, "mode", "m", "", "Storage mode: archive or snapshot (default from project config)")
+	createCmd.Flags().StringVarP(&backupFormat, "format", "f", "", "Archive format: zip, zip-store, tar.gz or tar.zst (default from project config)")
+	createCmd.Flags().StringVar(&backupLinks, "symlinks", "", "Symlinks: preserve, follow or skip (default from project config)")
+	createCmd.Flags().IntVarP(&backupJobs, "jobs", "j", 0, "Number of files compressed in parallel (default from project config)")
+	createCmd.Flags().BoolVar(&backupNoCache, "no-cache", false, "Ignore the file-state cache and re-read every file")
+	createCmd.Flags().BoolVar(&backupChanged, "if-changed", false, "Create nothing and exit with status 3 if the project did not change since the newest backup")
+	createCmd.Flags().BoolVar(&backupPinned, "pin", false, "Protect backup from pruning")
+	createCmd.Flags().BoolVar(&createDryRun, "dry-run", false, "Show what would be backed up without creating a backup")
 }
 
 func runCreate(cmd *cobra.Command, args []string) {
@@ -35,48 +90,74 @@ func runCreate(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	if _, configErr := config.LoadProjectConfig(currentDir); configErr != nil {
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
 		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
 		return
 	}
 
-	fmt.Println(ui.Info("Preparing to create backup..."))
+	if createDryRun {
+		ctx, stop := interruptContext()
+		defer stop()
 
-	var bar *progressbar.ProgressBar
-
-	metadata, err := backup.CreateBackup(currentDir, backupName, func(progress backup.ArchiveProgress) {
-		if bar == nil {
-			bar = progressbar.NewOptions(progress.Total,
-				progressbar.OptionSetDescription("Archiving"),
-				progressbar.OptionSetWidth(50),
-				progressbar.OptionShowCount(),
-				progressbar.OptionShowIts(),
-				progressbar.OptionSetTheme(progressbar.Theme{
-					Saucer:        "█",
-					SaucerPadding: "░",
-					BarStart:      "▐",
-					BarEnd:        "▌",
-				}))
+		plan, planErr := backup.PlanBackup(ctx, currentDir, projectConfig, backupLinks)
+		if planErr != nil {
+			fmt.Println(ui.Error(fmt.Sprintf("Failed to scan project: %v", planErr)))
+			return
 		}
-		bar.Set(progress.Current)
-		if progress.Current < progress.Total {
-			bar.Describe(fmt.Sprintf("Archiving: %s", progress.File))
-		}
-	})
+		printBackupPlan(plan)
+		return
+	}
+
+	fmt.Println(ui.Info("Preparing to create backup..."))
+
+	progress := &bytesProgress{action: "Archiving"}
+
+	options := backup.CreateOptions{
+		Name:        backupName,
+		Storage:     backupMode,
+		Format:      backupFormat,
+		Symlinks:    backupLinks,
+		Parallelism: backupJobs,
+		NoCache:     backupNoCache,
+		IfChanged:   projectConfig.SkipUnchanged,
+		Pinned:      backupPinned,
+	}
+
+	if cmd.Flags().Changed("if-changed") {
+		options.IfChanged = backupChanged
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	metadata, err := backup.CreateBackup(ctx, currentDir, options, progress.update)
+
+	var unchanged *backup.UnchangedError
+	if errors.As(err, &unchanged) {
+		fmt.Println(ui.Info(fmt.Sprintf("Nothing changed since %s, no backup created", getDisplayName(unchanged.Backup))))
+		os.Exit(exitUnchanged)
+	}
 
 	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Printf("\n%s\n", ui.Warning("Backup cancelled, nothing was saved"))
+			return
+		}
 		fmt.Printf("\n%s\n", ui.Error(fmt.Sprintf("Failed to create backup: %v", err)))
 		return
 	}
 
-	if bar != nil {
-		bar.Finish()
-	}
+	progress.finish()
 
 	fmt.Printf("\n%s\n", ui.Success("Backup successfully created!"))
 	fmt.Println()
 	fmt.Println(ui.Label("Name", getDisplayName(metadata)))
-	fmt.Println(ui.Label("Size", fmt.Sprintf("%.2f MB", float64(metadata.Size)/(1024*1024))))
+	fmt.Println(ui.Label("Size", fmt.Sprintf("%.2f MB", float64(metadata.UncompressedSize)/(1024*1024))))
+	fmt.Println(ui.Label("Stored", fmt.Sprintf("%.2f MB", float64(metadata.AddedSize)/(1024*1024))))
+	if metadata.Format != "" {
+		fmt.Println(ui.Label("Format", metadata.Format))
+	}
 	fmt.Println(ui.Label("Created", metadata.CreatedAt.Format("2006-01-02 15:04:05")))
 	fmt.Println(ui.Label("Path", metadata.FilePath))
 }
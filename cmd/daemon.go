@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/daemon"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run scheduled backups in the background",
+	Long: `The daemon command runs the scheduler in the foreground until it is
+stopped with Ctrl+C or 'backup daemon stop'. Run it from a service
+manager (systemd, launchd, Task Scheduler) to keep it going.
+
+Every project with schedules (see 'backup schedule') is backed up on
+time; after each backup the retention rules are applied. Backups
+are tagged "scheduled". Runs missed while the daemon was stopped
+are made up once at startup. Every run, including failures, is
+recorded in the project's schedule history.
+
+The daemon listens on the daemon.sock socket in the storage root;
+'backup daemon status', 'reload' and 'stop' talk to it.`,
+	Args: cobra.NoArgs,
+	Run:  runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show scheduled jobs of the running daemon",
+	Args:  cobra.NoArgs,
+	Run:   runDaemonStatus,
+}
+
+var daemonReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Make the running daemon re-read project schedules",
+	Args:  cobra.NoArgs,
+	Run:   runDaemonReload,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	Run:   runDaemonStop,
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd, daemonReloadCmd, daemonStopCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) {
+	scheduler, err := daemon.New(daemon.Options{
+		OnRun:    printScheduledRun,
+		OnReload: printDaemonReload,
+		OnError: func(err error) {
+			fmt.Println(ui.Warning(fmt.Sprintf("%s  %v", time.Now().Format("15:04:05"), err)))
+		},
+	})
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to start daemon: %v", err)))
+		return
+	}
+	defer scheduler.Close()
+
+	socketPath, _ := daemon.SocketPath()
+	fmt.Println(ui.Info(fmt.Sprintf("Backup daemon listening on %s", socketPath)))
+	fmt.Println(ui.Hint("Press Ctrl+C to stop"))
+	fmt.Println()
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	scheduler.Run(ctx)
+
+	fmt.Println()
+	fmt.Println(ui.Info("Daemon stopped"))
+}
+
+func printDaemonReload(projects, schedules int) {
+	stamp := time.Now().Format("15:04:05")
+	if schedules == 0 {
+		fmt.Println(ui.Hint(fmt.Sprintf("%s  No schedules yet. Add one with 'backup schedule add' in a project", stamp)))
+		return
+	}
+	fmt.Println(ui.Info(fmt.Sprintf("%s  Loaded %d schedule(s) in %d project(s)", stamp, schedules, projects)))
+}
+
+func printScheduledRun(job daemon.Job, run *backup.ScheduleRun) {
+	stamp := run.FinishedAt.Format("15:04:05")
+
+	switch run.Status {
+	case backup.RunSucceeded:
+		message := fmt.Sprintf("%s  %s: backup created (%s), %.2f MB added", stamp, job.ProjectName, job.Spec, float64(run.AddedSize)/(1024*1024))
+		if run.Pruned > 0 {
+			message += fmt.Sprintf(", %d old backup(s) removed", run.Pruned)
+		}
+		fmt.Println(ui.Success(message))
+		if run.Error != "" {
+			fmt.Println(ui.Warning(fmt.Sprintf("%s  %s: %s", stamp, job.ProjectName, run.Error)))
+		}
+	case backup.RunUnchanged:
+		fmt.Println(ui.Hint(fmt.Sprintf("%s  %s: no changes, no backup created (%s)", stamp, job.ProjectName, job.Spec)))
+	default:
+		fmt.Println(ui.Error(fmt.Sprintf("%s  %s: backup failed (%s): %s", stamp, job.ProjectName, job.Spec, run.Error)))
+	}
+
+	fmt.Println(ui.Hint(fmt.Sprintf("%s  %s: next run %s", stamp, job.ProjectName, job.Next.Format("2006-01-02 15:04"))))
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) {
+	status, ok := sendDaemon(daemon.CommandStatus)
+	if !ok {
+		return
+	}
+
+	fmt.Println(ui.Success("Daemon is running"))
+	fmt.Println(ui.Label("PID", fmt.Sprintf("%d", status.PID)))
+	fmt.Println(ui.Label("Started", status.StartedAt.Format("2006-01-02 15:04:05")))
+	fmt.Println()
+
+	if len(status.Jobs) == 0 {
+		fmt.Println(ui.Info("No schedules. Add one with 'backup schedule add' in a project"))
+		return
+	}
+
+	for _, job := range status.Jobs {
+		next := job.Next.Format("2006-01-02 15:04")
+		if job.Running {
+			next = "running now"
+		}
+		fmt.Printf("%-20s %-8s %-20s | next %-16s | %s\n",
+			job.ProjectName, job.ScheduleID, job.Spec, next, describeLastRun(job.LastRun))
+	}
+}
+
+func runDaemonReload(cmd *cobra.Command, args []string) {
+	if _, ok := sendDaemon(daemon.CommandReload); ok {
+		fmt.Println(ui.Success("Daemon is reloading schedules"))
+	}
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) {
+	if _, ok := sendDaemon(daemon.CommandStop); ok {
+		fmt.Println(ui.Success("Daemon is stopping"))
+	}
+}
+
+func sendDaemon(command string) (*daemon.Status, bool) {
+	status, err := daemon.Send(command)
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println(ui.Warning("Daemon is not running. Start it with 'backup daemon'"))
+		return nil, false
+	}
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to reach daemon: %v", err)))
+		return nil, false
+	}
+	return status, true
+}
+
+func notifyDaemon() {
+	if _, err := daemon.Send(daemon.CommandReload); err == nil {
+		fmt.Println(ui.Hint("Running daemon picked up the change"))
+		return
+	}
+	fmt.Println(ui.Hint("Schedules run while 'backup daemon' is running"))
+}
+
+func describeLastRun(run *backup.ScheduleRun) string {
+	if run == nil {
+		return "never ran"
+	}
+
+	when := run.StartedAt.Format("2006-01-02 15:04")
+	switch run.Status {
+	case backup.RunSucceeded:
+		if run.Error != "" {
+			return fmt.Sprintf("last %s ok, %s", when, run.Error)
+		}
+		return fmt.Sprintf("last %s ok", when)
+	case backup.RunUnchanged:
+		return fmt.Sprintf("last %s unchanged", when)
+	default:
+		return fmt.Sprintf("last %s FAILED: %s", when, run.Error)
+	}
+}
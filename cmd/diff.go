@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <backup> [backup]",
+	Short: "Show changes between backups or a backup and current directory",
+	Long: `The diff command compares two backups, or a backup with the
+current directory (excluded paths are ignored).
+Lists added, removed and modified files with size changes.
+Backup can be selected by ID, ID prefix, name or number in the list.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  runDiff,
+}
+
+var (
+	diffPatch bool
+	diffStat  bool
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVarP(&diffPatch, "patch", "p", false, "Show unified diff for text files")
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "Show only summary")
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	from, err := backup.FindBackup(backups, args[0])
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	var diff *backup.Diff
+	toLabel := "current directory"
+
+	if len(args) == 2 {
+		to, findErr := backup.FindBackup(backups, args[1])
+		if findErr != nil {
+			fmt.Println(ui.Error(findErr.Error()))
+			return
+		}
+		toLabel = getDisplayName(to)
+		diff, err = backup.DiffBackups(from, to)
+	} else {
+		diff, err = backup.DiffWorkingTree(from, currentDir, backup.NewMatcher(currentDir, projectConfig), false)
+	}
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to compare: %v", err)))
+		return
+	}
+	defer diff.Close()
+
+	fmt.Println(ui.Info(fmt.Sprintf("Changes from '%s' to %s", getDisplayName(from), toLabel)))
+	fmt.Println()
+
+	if !diffStat {
+		printChanges(diff.Changes, 0)
+	}
+
+	if diffPatch {
+		for _, change := range diff.Changes {
+			patch, patchErr := diff.Patch(change)
+			if patchErr != nil {
+				fmt.Println(ui.Error(fmt.Sprintf("Failed to diff %s: %v", change.Path, patchErr)))
+				continue
+			}
+			fmt.Println()
+			printPatch(patch)
+		}
+	}
+
+	fmt.Println()
+	printDiffStat(diff.Stat())
+}
+
+func printChanges(changes []backup.FileChange, limit int) {
+	for i, change := range changes {
+		if limit > 0 && i == limit {
+			fmt.Println(ui.HintStyle.Render(fmt.Sprintf("  ... and %d more", len(changes)-limit)))
+			return
+		}
+
+		delta := formatSizeDelta(change.SizeDelta())
+		switch change.Type {
+		case backup.ChangeAdded:
+			fmt.Println(ui.Added(fmt.Sprintf("  A  %-60s %s", change.Path, delta)))
+		case backup.ChangeRemoved:
+			fmt.Println(ui.Removed(fmt.Sprintf("  D  %-60s %s", change.Path, delta)))
+		case backup.ChangeModified:
+			fmt.Println(ui.Modified(fmt.Sprintf("  M  %-60s %s", change.Path, delta)))
+		}
+	}
+}
+
+func printPatch(patch string) {
+	for _, line := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Println(ui.ValueStyle.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(ui.InfoStyle.Render(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(ui.Added(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(ui.Removed(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+func printDiffStat(stat backup.DiffStat) {
+	total := stat.Added + stat.Removed + stat.Modified
+	if total == 0 {
+		fmt.Println(ui.Success("No changes"))
+		return
+	}
+
+	fmt.Println(ui.Label("Files changed", fmt.Sprintf("%d (%d added, %d removed, %d modified)",
+		total, stat.Added, stat.Removed, stat.Modified)))
+	fmt.Println(ui.Label("Size change", formatSizeDelta(stat.SizeDelta)))
+}
+
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + ui.FormatSize(-delta)
+	}
+	return "+" + ui.FormatSize(delta)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var encryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Enable or disable backup encryption",
+	Long: `The encryption command manages encryption of new backups.
+
+Encrypted backups are protected with AES-256-GCM using a key derived
+from a passphrase with scrypt. The passphrase is read from --key-file,
+the key file configured for the project, the environment variable
+configured for the project (BACKUP_PASSPHRASE by default),
+or asked interactively.
+
+Existing backups are not re-encrypted. Encrypted backups can be listed
+without the passphrase; load, restore, diff and verify need it.`,
+}
+
+var encryptionEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Encrypt new backups of this project",
+	Args:  cobra.NoArgs,
+	Run:   runEncryptionEnable,
+}
+
+var encryptionDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop encrypting new backups",
+	Args:  cobra.NoArgs,
+	Run:   runEncryptionDisable,
+}
+
+var encryptionStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show encryption settings",
+	Args:  cobra.NoArgs,
+	Run:   runEncryptionStatus,
+}
+
+var (
+	encryptionKeyFile       string
+	encryptionPassphraseEnv string
+)
+
+func init() {
+	rootCmd.AddCommand(encryptionCmd)
+	encryptionCmd.AddCommand(encryptionEnableCmd, encryptionDisableCmd, encryptionStatusCmd)
+	encryptionEnableCmd.Flags().StringVar(&encryptionKeyFile, "use-key-file", "", "Read passphrase from this file for this project (stored in project config)")
+	encryptionEnableCmd.Flags().StringVar(&encryptionPassphraseEnv, "passphrase-env", "", "Read passphrase from this environment variable (stored in project config)")
+}
+
+func runEncryptionEnable(cmd *cobra.Command, args []string) {
+	currentDir, projectConfig, ok := loadEncryptionProject()
+	if !ok {
+		return
+	}
+
+	if projectConfig.Encryption != nil && projectConfig.Encryption.Enabled {
+		fmt.Println(ui.Info("Encryption is already enabled"))
+		return
+	}
+
+	passphrase, err := newPassphrase(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	if err := backup.EnableEncryption(currentDir, passphrase, encryptionKeyFile, encryptionPassphraseEnv); err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to enable encryption: %v", err)))
+		return
+	}
+
+	fmt.Println(ui.Success("Encryption enabled. New backups will be encrypted"))
+	fmt.Println(ui.Hint("Keep the passphrase safe: encrypted backups cannot be restored without it"))
+}
+
+func newPassphrase(projectDir string) (string, error) {
+	path := encryptionKeyFile
+	if path == "" {
+		path = keyFile
+	}
+	if path != "" {
+		return readKeyFile(path, projectDir)
+	}
+
+	envName := passphraseEnv
+	if encryptionPassphraseEnv != "" {
+		envName = encryptionPassphraseEnv
+	}
+	if passphrase := os.Getenv(envName); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := promptPassphrase("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirmation, err := promptPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirmation {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func runEncryptionDisable(cmd *cobra.Command, args []string) {
+	currentDir, projectConfig, ok := loadEncryptionProject()
+	if !ok {
+		return
+	}
+
+	if projectConfig.Encryption == nil || !projectConfig.Encryption.Enabled {
+		fmt.Println(ui.Info("Encryption is not enabled"))
+		return
+	}
+
+	projectConfig.Encryption.Enabled = false
+	if err := projectConfig.Save(currentDir); err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	fmt.Println(ui.Success("Encryption disabled. New backups will not be encrypted"))
+	fmt.Println(ui.Hint("Existing encrypted backups still need the passphrase"))
+}
+
+func runEncryptionStatus(cmd *cobra.Command, args []string) {
+	currentDir, projectConfig, ok := loadEncryptionProject()
+	if !ok {
+		return
+	}
+
+	settings := projectConfig.Encryption
+	if settings == nil {
+		settings = &config.EncryptionConfig{}
+	}
+
+	status := "disabled"
+	if settings.Enabled {
+		status = "enabled (AES-256-GCM, scrypt)"
+	}
+	fmt.Println(ui.Label("Encryption", status))
+
+	source := "prompt or " + passphraseEnv
+	switch {
+	case settings.KeyFile != "":
+		source = "key file " + settings.KeyFile
+	case settings.PassphraseEnv != "":
+		source = "environment variable " + settings.PassphraseEnv
+	}
+	fmt.Println(ui.Label("Passphrase source", source))
+
+	if backups, err := backup.LoadBackupMetadata(currentDir); err == nil {
+		encrypted := 0
+		for _, b := range backups {
+			if b.Encrypted {
+				encrypted++
+			}
+		}
+		fmt.Println(ui.Label("Encrypted backups", fmt.Sprintf("%d of %d", encrypted, len(backups))))
+	}
+}
+
+func loadEncryptionProject() (string, *config.ProjectConfig, bool) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return "", nil, false
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return "", nil, false
+	}
+
+	return currentDir, projectConfig, true
+}
@@ -16,7 +16,8 @@ var initCmd = &cobra.Command{
 	Short: "Initialize current directory for backup management",
 	Long: `The init command creates project configuration for the backup system.
 Creates .backup-config.json file with unique project ID
-and sets up backup directory in %APPDATA%/ProjectBackup.`,
+and sets up backup directory in the storage root
+(see "backup --help").`,
 	Run: runInit,
 }
 
@@ -3,8 +3,6 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"strconv"
-	"strings"
 
 	"backup-tool/internal/config"
 	"backup-tool/internal/ui"
@@ -18,8 +16,8 @@ var listCmd = &cobra.Command{
 	Long: `The list command shows all created backups in interactive mode.
 Allows to select backup and perform actions:
 - Enter: load backup
-- r: rename backup  
-- d: delete backup
+- r: rename backup (inline, Enter to save, Esc to cancel)
+- d: delete backup (asks for confirmation)
 - q: quit`,
 	Run: runList,
 }
@@ -35,42 +33,21 @@ func runList(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	if _, configErr := config.LoadProjectConfig(currentDir); configErr != nil {
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
 		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
 		return
 	}
 
-	choice, err := ui.RunListUI(currentDir)
+	selectedBackup, err := chooseBackupFromList(currentDir)
 	if err != nil {
 		fmt.Println(ui.Error(fmt.Sprintf("Error: %v", err)))
 		return
 	}
 
-	if choice == "" || choice == "quit" {
+	if selectedBackup == nil {
 		return
 	}
 
-	parts := strings.Split(choice, ":")
-	if len(parts) != 2 {
-		return
-	}
-
-	action := parts[0]
-	index, err := strconv.Atoi(parts[1])
-	if err != nil {
-		fmt.Println(ui.Error(fmt.Sprintf("Invalid index: %v", err)))
-		return
-	}
-
-	switch action {
-	case "load":
-		fmt.Println(ui.Progress(fmt.Sprintf("Loading backup (index: %d)...", index)))
-		fmt.Println(ui.Warning("Load function will be implemented in 'backup load' command"))
-	case "rename":
-		fmt.Println(ui.Progress(fmt.Sprintf("Renaming backup (index: %d)...", index)))
-		fmt.Println(ui.Warning("Rename function will be added later"))
-	case "delete":
-		fmt.Println(ui.Progress(fmt.Sprintf("Deleting backup (index: %d)...", index)))
-		fmt.Println(ui.Warning("Delete function will be added later"))
-	}
+	loadBackup(currentDir, projectConfig, selectedBackup)
 }
@@ -1,17 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
 
 	"backup-tool/internal/backup"
 	"backup-tool/internal/config"
+	"backup-tool/internal/ignore"
 	"backup-tool/internal/ui"
 
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
@@ -22,15 +21,33 @@ var loadCmd = &cobra.Command{
 Without parameters opens interactive backup list.
 With --name parameter loads specific backup by name.
 
-WARNING: All files in current directory will be deleted!`,
+Files that are not present in the backup are deleted.
+Excluded paths (.git, node_modules, .env etc.) are left in place.
+With --clean-all all files in current directory are deleted first.
+
+Backup is extracted into a staging directory next to the project
+and verified before any file is touched. If anything fails, the
+project is rolled back. Previous state is saved as a pre-restore
+backup that can be brought back with 'backup undo'.
+
+File modes, modification times and empty directories are restored.
+Symbolic links are handled according to --symlinks: preserve
+recreates them, follow replaces links to files with copies of
+their targets, skip leaves them out.`,
 	Run: runLoad,
 }
 
-var loadBackupName string
+var (
+	loadBackupName string
+	loadCleanAll   bool
+	loadSymlinks   string
+)
 
 func init() {
 	rootCmd.AddCommand(loadCmd)
 	loadCmd.Flags().StringVarP(&loadBackupName, "name", "n", "", "Backup name to load")
+	loadCmd.Flags().BoolVar(&loadCleanAll, "clean-all", false, "Delete all files including excluded paths before restoring")
+	loadCmd.Flags().StringVar(&loadSymlinks, "symlinks", "", "Symlinks: preserve, follow or skip (default from project config)")
 }
 
 func runLoad(cmd *cobra.Command, args []string) {
@@ -71,43 +88,61 @@ func runLoad(cmd *cobra.Command, args []string) {
 			return
 		}
 	} else {
-		choice, listErr := ui.RunListUI(currentDir)
+		selected, listErr := chooseBackupFromList(currentDir)
 		if listErr != nil {
 			fmt.Println(ui.Error(fmt.Sprintf("Error: %v", listErr)))
 			return
 		}
 
-		if choice == "" || choice == "quit" {
+		if selected == nil {
 			return
 		}
 
-		parts := strings.Split(choice, ":")
-		if len(parts) != 2 || parts[0] != "load" {
-			fmt.Println(ui.Error("Invalid choice"))
-			return
-		}
+		selectedBackup = selected
+	}
 
-		index, parseErr := strconv.Atoi(parts[1])
-		if parseErr != nil || index < 0 || index >= len(backups) {
-			fmt.Println(ui.Error("Invalid backup index"))
-			return
-		}
+	loadBackup(currentDir, projectConfig, selectedBackup)
+}
+
+func chooseBackupFromList(currentDir string) (*config.BackupMetadata, error) {
+	choice, err := ui.RunListUI(currentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	action, id, found := strings.Cut(choice, ":")
+	if !found || action != "load" {
+		return nil, nil
+	}
 
-		selectedBackup = backups[index]
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backup list: %v", err)
 	}
 
+	return backup.FindBackup(backups, id)
+}
+
+func loadBackup(currentDir string, projectConfig *config.ProjectConfig, selectedBackup *config.BackupMetadata) {
 	displayName := selectedBackup.Name
 	if displayName == "" {
 		displayName = selectedBackup.CreatedAt.Format("2006-01-02 15:04:05")
 	}
 
-	fmt.Println(ui.Warning("WARNING: All files in current directory will be deleted!"))
+	if loadCleanAll {
+		fmt.Println(ui.Warning("WARNING: All files in current directory will be deleted!"))
+	} else {
+		fmt.Println(ui.Warning("WARNING: Files not present in backup will be deleted!"))
+		fmt.Println(ui.Hint("Excluded paths (.git, node_modules, .env etc.) are kept"))
+	}
 	fmt.Println()
 	fmt.Println(ui.Label("Backup to load", displayName))
 	fmt.Println(ui.Label("Created", selectedBackup.CreatedAt.Format("2006-01-02 15:04:05")))
 	fmt.Println(ui.Label("Size", fmt.Sprintf("%.2f MB", float64(selectedBackup.Size)/(1024*1024))))
 	fmt.Println()
 
+	printLoadPreview(currentDir, selectedBackup, backup.NewMatcher(currentDir, projectConfig))
+
 	fmt.Print(ui.ValueStyle.Render("Continue? (y/N): "))
 	var response string
 	fmt.Scanln(&response)
@@ -117,74 +152,71 @@ func runLoad(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	fmt.Println(ui.Info("Clearing current directory..."))
-	if clearErr := clearDirectory(currentDir, projectConfig.ID); clearErr != nil {
-		fmt.Println(ui.Error(fmt.Sprintf("Failed to clear directory: %v", clearErr)))
-		return
-	}
-
 	fmt.Println(ui.Info("Restoring from backup..."))
 
-	var bar *progressbar.ProgressBar
-
-	err = backup.RestoreBackup(selectedBackup.FilePath, currentDir, func(progress backup.ArchiveProgress) {
-		if bar == nil {
-			bar = progressbar.NewOptions(progress.Total,
-				progressbar.OptionSetDescription("Restoring"),
-				progressbar.OptionSetWidth(50),
-				progressbar.OptionShowCount(),
-				progressbar.OptionShowIts(),
-				progressbar.OptionSetTheme(progressbar.Theme{
-					Saucer:        "█",
-					SaucerPadding: "░",
-					BarStart:      "▐",
-					BarEnd:        "▌",
-				}))
-		}
-		bar.Set(progress.Current)
-		if progress.Current < progress.Total {
-			bar.Describe(fmt.Sprintf("Restoring: %s", progress.File))
-		}
-	})
+	ctx, stop := interruptContext()
+	defer stop()
 
+	result, err := restoreWithProgress(ctx, currentDir, selectedBackup)
 	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Printf("\n%s\n", ui.Warning("Load cancelled, project left unchanged"))
+			return
+		}
 		fmt.Printf("\n%s\n", ui.Error(fmt.Sprintf("Restore failed: %v", err)))
+		printRestoreProblems(err)
 		return
 	}
 
-	if bar != nil {
-		bar.Finish()
-	}
-
 	fmt.Printf("\n%s\n", ui.Success("Backup successfully loaded!"))
 	fmt.Println()
 	fmt.Println(ui.Label("Restored backup", displayName))
 	fmt.Println(ui.Label("Directory", currentDir))
+	fmt.Println(ui.Label("Restored files", fmt.Sprintf("%d", result.Restored)))
+	fmt.Println(ui.Label("Removed files", fmt.Sprintf("%d", result.Removed)))
+	if result.SafetyBackup != nil {
+		fmt.Println()
+		fmt.Println(ui.Hint("Previous state saved as pre-restore backup. Run 'backup undo' to bring it back"))
+	}
 }
 
-func clearDirectory(dir string, _ string) error {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return err
+func restoreWithProgress(ctx context.Context, projectPath string, selectedBackup *config.BackupMetadata) (*backup.RestoreResult, error) {
+	if verification := verifyWithProgress(selectedBackup); !verification.OK() {
+		return nil, verification.Err()
 	}
 
-	for _, entry := range entries {
-		path := filepath.Join(dir, entry.Name())
+	progress := &bytesProgress{action: "Restoring"}
 
-		if entry.Name() == config.ConfigFileName {
-			continue
-		}
+	options := backup.RestoreOptions{
+		CleanAll: loadCleanAll,
+		Symlinks: loadSymlinks,
+	}
 
-		if entry.IsDir() {
-			if err := os.RemoveAll(path); err != nil {
-				return err
-			}
-		} else {
-			if err := os.Remove(path); err != nil {
-				return err
-			}
+	result, err := backup.RestoreProject(ctx, projectPath, selectedBackup, options, progress.update)
+	progress.finish()
+
+	return result, err
+}
+
+func printLoadPreview(projectPath string, selectedBackup *config.BackupMetadata, matcher *ignore.Matcher) {
+	diff, err := backup.DiffWorkingTree(selectedBackup, projectPath, matcher, true)
+	if err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("Failed to preview changes: %v", err)))
+		fmt.Println()
+		return
+	}
+	defer diff.Close()
+
+	changes := diff.Changes[:0]
+	for _, change := range diff.Changes {
+		if change.Path != config.ConfigFileName {
+			changes = append(changes, change)
 		}
 	}
+	diff.Changes = changes
 
-	return nil
+	fmt.Println(ui.Info("Changes to current directory:"))
+	printChanges(diff.Changes, 20)
+	printDiffStat(diff.Stat())
+	fmt.Println()
 }
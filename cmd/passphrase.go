@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backup-tool/internal/config"
+
+	"golang.org/x/term"
+)
+
+const passphraseEnv = "BACKUP_PASSPHRASE"
+
+var keyFile string
+
+func readPassphrase(projectDir string, settings *config.EncryptionConfig) (string, error) {
+	if keyFile != "" {
+		return readKeyFile(keyFile, "")
+	}
+	if settings == nil {
+		settings = &config.EncryptionConfig{}
+	}
+	if settings.KeyFile != "" {
+		return readKeyFile(settings.KeyFile, projectDir)
+	}
+
+	envName := passphraseEnv
+	if settings.PassphraseEnv != "" {
+		envName = settings.PassphraseEnv
+	}
+	if passphrase := os.Getenv(envName); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if projectDir != "" && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no passphrase for %s: set key_file with 'backup encryption enable --use-key-file' or set %s", projectDir, envName)
+	}
+	return promptPassphrase("Passphrase: ")
+}
+
+func readKeyFile(path string, projectDir string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectDir, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %v", err)
+	}
+
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return passphrase, nil
+}
+
+func promptPassphrase(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no passphrase: use --key-file or set %s", passphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin <backup>",
+	Short: "Protect backup from pruning",
+	Long: `The pin command marks backup as pinned.
+Pinned backups are never removed by 'backup prune' unless --force is given.
+Backup can be selected by ID, ID prefix, name or number in the list.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPin,
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin <backup>",
+	Short: "Remove pruning protection from backup",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPin,
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
+
+func runPin(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	if _, configErr := config.LoadProjectConfig(currentDir); configErr != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	selected, err := backup.FindBackup(backups, args[0])
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	pinned := cmd.Name() == "pin"
+	if _, err := backup.UpdateBackup(currentDir, selected.ID, func(b *config.BackupMetadata) {
+		b.Pinned = pinned
+	}); err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to update backup: %v", err)))
+		return
+	}
+
+	if pinned {
+		fmt.Println(ui.Success(fmt.Sprintf("Backup '%s' pinned", getDisplayName(selected))))
+	} else {
+		fmt.Println(ui.Success(fmt.Sprintf("Backup '%s' unpinned", getDisplayName(selected))))
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/ui"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+func newProgressBar(total int, description string) *progressbar.ProgressBar {
+	return progressbar.NewOptions(total,
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWidth(50),
+		progressbar.OptionSetVisibility(!globalConfig.UI.HideProgress),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetTheme(progressTheme))
+}
+
+func newBytesProgressBar(total int64, description string) *progressbar.ProgressBar {
+	return progressbar.NewOptions64(total,
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionSetVisibility(!globalConfig.UI.HideProgress),
+		progressbar.OptionSetPredictTime(false),
+		progressbar.OptionSetElapsedTime(false),
+		progressbar.OptionShowDescriptionAtLineEnd(),
+		progressbar.OptionSetTheme(progressTheme))
+}
+
+var progressTheme = progressbar.Theme{
+	Saucer:        "█",
+	SaucerPadding: "░",
+	BarStart:      "▐",
+	BarEnd:        "▌",
+}
+
+type bytesProgress struct {
+	action string
+	bar    *progressbar.ProgressBar
+}
+
+func (p *bytesProgress) update(progress backup.ArchiveProgress) {
+	if p.bar == nil {
+		p.bar = newBytesProgressBar(progress.TotalBytes, p.action)
+	}
+	p.bar.Describe(describeProgress(p.action, progress))
+	p.bar.Set64(progress.Bytes)
+}
+
+func (p *bytesProgress) finish() {
+	if p.bar != nil {
+		p.bar.Finish()
+	}
+}
+
+func describeProgress(action string, progress backup.ArchiveProgress) string {
+	description := fmt.Sprintf("%s %s/%s", action, ui.FormatSize(progress.Bytes), ui.FormatSize(progress.TotalBytes))
+	if progress.Throughput > 0 {
+		description += fmt.Sprintf(", %s/s", ui.FormatSize(int64(progress.Throughput)))
+	}
+	if progress.ETA > 0 {
+		description += ", " + progress.ETA.Round(time.Second).String() + " left"
+	}
+	if progress.File != "" {
+		description += fmt.Sprintf(" | %s (%d%%)", progress.File, progress.FilePercent())
+	}
+	return description
+}
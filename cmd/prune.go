@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old backups according to retention rules",
+	Long: `The prune command applies retention rules from project configuration
+(keep_last, keep_daily, keep_weekly, keep_monthly, max_age_days,
+max_total_size_mb) and removes backups that are no longer needed.
+Backups kept by a keep_* rule are not removed by max_age_days or
+max_total_size_mb. Named and pinned backups are kept unless --force
+is given; pre-restore backups used by 'backup undo' are never pruned.
+
+Automatic backups made by 'backup watch' are thinned by the separate
+auto_retention rules and never count against the regular ones.`,
+	Run: runPrune,
+}
+
+var (
+	pruneDryRun bool
+	pruneForce  bool
+)
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Only show what would be removed")
+	pruneCmd.Flags().BoolVarP(&pruneForce, "force", "f", false, "Also remove named and pinned backups")
+}
+
+func runPrune(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	policy := projectConfig.EffectiveRetention()
+	autoPolicy := projectConfig.EffectiveAutoRetention()
+	if policy.IsEmpty() {
+		fmt.Println(ui.Hint("No retention rules for manual backups, only automatic ones are pruned. Use 'backup config set retention.keep_last 10' (or --global)"))
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	decisions := backup.PlanRetention(backups, policy, autoPolicy, time.Now(), pruneForce)
+
+	var toRemove []string
+	var reclaimable int64
+
+	for _, d := range decisions {
+		line := fmt.Sprintf("%-6s %-30s | %-19s | %8.2f MB | %s",
+			"keep", getDisplayName(d.Backup), d.Backup.CreatedAt.Format("2006-01-02 15:04:05"),
+			float64(d.Backup.AddedSize)/(1024*1024), d.Reason)
+
+		if d.Keep {
+			fmt.Println(ui.SecondaryStyle.Render(line))
+			continue
+		}
+
+		line = "remove" + line[len("keep  "):]
+		fmt.Println(ui.WarningStyle.Render(line))
+		toRemove = append(toRemove, d.Backup.ID)
+		reclaimable += d.Backup.AddedSize
+	}
+
+	fmt.Println()
+
+	if len(toRemove) == 0 {
+		fmt.Println(ui.Info("Nothing to prune"))
+		return
+	}
+
+	if pruneDryRun {
+		fmt.Println(ui.Info(fmt.Sprintf("Dry run: %d backup(s) would be removed, about %.2f MB reclaimed",
+			len(toRemove), float64(reclaimable)/(1024*1024))))
+		return
+	}
+
+	freed, err := backup.DeleteBackups(currentDir, toRemove)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to prune backups: %v", err)))
+		return
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Removed %d backup(s)", len(toRemove))))
+	fmt.Println(ui.Label("Freed", fmt.Sprintf("%.2f MB", float64(freed)/(1024*1024))))
+}
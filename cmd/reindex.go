@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild backup catalog from archives on disk",
+	Long: `The reindex command scans the project backup directory
+and rebuilds the backup catalog from existing archives.
+Names and IDs of backups already present in the catalog are kept.
+Archives and snapshots that cannot be read (damaged, truncated or
+encrypted with an unavailable key) are reported and left out.
+If the recorded backup_path no longer exists, the directory that is
+used instead is written to the project config.`,
+	Run: runReindex,
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+}
+
+func runReindex(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	fmt.Println(ui.Info("Rebuilding backup catalog..."))
+
+	backups, skipped, err := backup.ReindexCatalog(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to rebuild catalog: %v", err)))
+		return
+	}
+
+	fmt.Println(ui.Success("Backup catalog successfully rebuilt!"))
+	fmt.Println()
+	fmt.Println(ui.Label("Backups", fmt.Sprintf("%d", len(backups))))
+	fmt.Println(ui.Label("Catalog", filepath.Join(projectConfig.BackupPath, backup.CatalogFileName)))
+
+	if projectConfig.MissingBackupPath() != "" {
+		projectConfig.AdoptBackupPath()
+		if err := projectConfig.Save(currentDir); err != nil {
+			fmt.Println(ui.Error(fmt.Sprintf("Failed to record backup path: %v", err)))
+		} else {
+			fmt.Println(ui.Label("Backup path", projectConfig.BackupPath+" (recorded in "+config.ConfigFileName+")"))
+		}
+	}
+
+	if len(skipped) > 0 {
+		fmt.Println()
+		for _, err := range skipped {
+			fmt.Println(ui.Warning(err.Error()))
+		}
+		fmt.Println(ui.Hint(fmt.Sprintf("%d unreadable file(s) left out of the catalog. Fix or remove them and run 'backup reindex' again", len(skipped))))
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <backup> <new-name>",
+	Short: "Rename backup",
+	Long: `The rename command changes the name of a backup.
+Backup can be selected by ID, ID prefix, name or number in the list.
+Pass an empty name ("") to remove the name.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runRename,
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
+
+func runRename(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	if _, configErr := config.LoadProjectConfig(currentDir); configErr != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	selected, err := backup.FindBackup(backups, args[0])
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	oldName := getDisplayName(selected)
+
+	renamed, err := backup.RenameBackup(currentDir, selected.ID, args[1])
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to rename backup: %v", err)))
+		return
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Backup '%s' renamed to '%s'", oldName, getDisplayName(renamed))))
+}
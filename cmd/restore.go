@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup> -- <path/glob>...",
+	Short: "Restore selected files from backup",
+	Long: `The restore command extracts only files matching given paths
+or glob patterns from backup. Directories are restored recursively
+and "." selects everything. Other files in the project and the
+project config (.backup-config.json) are not touched.
+
+Existing files are handled according to --conflict:
+- rename: keep current file as <name>.orig (default); undone if
+  the file then cannot be restored
+- overwrite: replace current file
+- skip: keep current file and do not restore
+
+File modes and modification times are restored. Symbolic links
+are handled according to --symlinks (preserve, follow or skip).`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runRestore,
+}
+
+var (
+	restoreTarget   string
+	restoreConflict string
+	restoreSymlinks string
+)
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringVarP(&restoreTarget, "target", "t", "", "Directory to restore into (default current directory)")
+	restoreCmd.Flags().StringVarP(&restoreConflict, "conflict", "c", string(backup.ConflictRename), "Conflict handling: overwrite, skip or rename")
+	restoreCmd.Flags().StringVar(&restoreSymlinks, "symlinks", "", "Symlinks: preserve, follow or skip (default from project config)")
+}
+
+func runRestore(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	symlinks := restoreSymlinks
+	if symlinks == "" {
+		symlinks = projectConfig.EffectiveSymlinks()
+	}
+
+	conflict, err := backup.ParseConflictPolicy(restoreConflict)
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	targetPath := currentDir
+	if restoreTarget != "" {
+		if targetPath, err = filepath.Abs(restoreTarget); err != nil {
+			fmt.Println(ui.Error(fmt.Sprintf("Invalid target directory: %v", err)))
+			return
+		}
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	selectedBackup, err := backup.FindBackup(backups, args[0])
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return
+	}
+
+	options := backup.PartialRestoreOptions{
+		Patterns:   args[1:],
+		TargetPath: targetPath,
+		Conflict:   conflict,
+		Symlinks:   symlinks,
+		Limits:     projectConfig.EffectiveRestoreLimits(),
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	result, err := backup.RestorePaths(ctx, selectedBackup, options, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println(ui.Warning("Restore cancelled, files listed below were restored"))
+		} else {
+			fmt.Println(ui.Error(fmt.Sprintf("Restore failed: %v", err)))
+			printRestoreProblems(err)
+		}
+		if result == nil {
+			return
+		}
+	}
+
+	for _, path := range result.Restored {
+		if renamed, ok := result.Renamed[path]; ok {
+			fmt.Println(ui.Added("  R  "+path) + ui.HintStyle.Render("  (current file kept as "+filepath.Base(renamed)+")"))
+		} else {
+			fmt.Println(ui.Added("  R  " + path))
+		}
+	}
+	for _, path := range result.Skipped {
+		fmt.Println(ui.SecondaryStyle.Render("  S  " + path + "  (exists, skipped)"))
+	}
+
+	if err != nil {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Success(fmt.Sprintf("Restored %d file(s) from '%s'", len(result.Restored), getDisplayName(selectedBackup))))
+	if len(result.Skipped) > 0 {
+		fmt.Println(ui.Label("Skipped", fmt.Sprintf("%d", len(result.Skipped))))
+	}
+	fmt.Println(ui.Label("Target", targetPath))
+}
+
+func printRestoreProblems(err error) {
+	if errors.Is(err, backup.ErrUnsafeArchive) {
+		fmt.Println(ui.Hint("The archive was rejected before extraction. If it is trusted and only exceeds the limits, raise restore.max_size_mb or restore.max_entries with 'backup config set'"))
+	}
+
+	var portability *backup.PortabilityError
+	if errors.As(err, &portability) {
+		for _, problem := range portability.Problems {
+			fmt.Println(ui.WarningStyle.Render("  !  " + problem))
+		}
+		fmt.Println(ui.Hint("Restore the other files with 'backup restore <backup> -- <path>...' or load the backup on a system that supports these names"))
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <backup>...",
+	Short: "Delete backups",
+	Long: `The rm command deletes one or more backups.
+Backup can be selected by ID, ID prefix, name or number in the list.
+With --older-than all backups older than given age are selected
+(for example 30d, 2w, 12h). Pinned backups require --force.`,
+	Run: runRm,
+}
+
+var (
+	rmOlderThan string
+	rmYes       bool
+	rmForce     bool
+)
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+	rmCmd.Flags().StringVar(&rmOlderThan, "older-than", "", "Delete backups older than given age (e.g. 30d, 2w, 12h)")
+	rmCmd.Flags().BoolVarP(&rmYes, "yes", "y", false, "Do not ask for confirmation")
+	rmCmd.Flags().BoolVarP(&rmForce, "force", "f", false, "Also delete pinned backups")
+}
+
+func runRm(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	if _, configErr := config.LoadProjectConfig(currentDir); configErr != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	if len(args) == 0 && rmOlderThan == "" {
+		fmt.Println(ui.Error("Specify backups to delete or --older-than"))
+		return
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	var selected []*config.BackupMetadata
+	seen := make(map[string]bool)
+
+	for _, arg := range args {
+		b, findErr := backup.FindBackup(backups, arg)
+		if findErr != nil {
+			fmt.Println(ui.Error(findErr.Error()))
+			return
+		}
+		if !seen[b.ID] {
+			seen[b.ID] = true
+			selected = append(selected, b)
+		}
+	}
+
+	if rmOlderThan != "" {
+		age, parseErr := backup.ParseAge(rmOlderThan)
+		if parseErr != nil {
+			fmt.Println(ui.Error(parseErr.Error()))
+			return
+		}
+		for _, b := range backup.SelectOlderThan(backups, age, time.Now()) {
+			if !seen[b.ID] {
+				seen[b.ID] = true
+				selected = append(selected, b)
+			}
+		}
+	}
+
+	var ids []string
+	for _, b := range selected {
+		if b.Pinned && !rmForce {
+			fmt.Println(ui.Warning(fmt.Sprintf("Skipping pinned backup '%s' (use --force)", getDisplayName(b))))
+			continue
+		}
+		ids = append(ids, b.ID)
+		fmt.Println(ui.Removed(fmt.Sprintf("  %-30s | %s", getDisplayName(b), b.CreatedAt.Format("2006-01-02 15:04:05"))))
+	}
+
+	if len(ids) == 0 {
+		fmt.Println(ui.Info("Nothing to delete"))
+		return
+	}
+
+	if !rmYes {
+		fmt.Println()
+		fmt.Print(ui.ValueStyle.Render(fmt.Sprintf("Delete %d backup(s)? (y/N): ", len(ids))))
+		var response string
+		fmt.Scanln(&response)
+
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println(ui.Warning("Operation cancelled"))
+			return
+		}
+	}
+
+	freed, err := backup.DeleteBackups(currentDir, ids)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to delete backups: %v", err)))
+		return
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Deleted %d backup(s)", len(ids))))
+	fmt.Println(ui.Label("Freed", fmt.Sprintf("%.2f MB", float64(freed)/(1024*1024))))
+}
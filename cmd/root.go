@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
 	"github.com/spf13/cobra"
 )
 
@@ -13,10 +17,69 @@ var rootCmd = &cobra.Command{
 	Long: `backup - CLI tool for creating, managing and restoring project backups.
 
 Supported commands:
-  init   - initialize directory for backups
-  create - create new project backup
-  list   - display list of all backups
-  load   - load backup into current directory`,
+  init         - initialize directory for backups
+  create       - create new project backup
+  list         - display list of all backups
+  load         - load backup into current directory
+  restore      - restore selected files from backup
+  rename       - rename backup
+  rm           - delete backups
+  undo         - undo last backup load
+  diff         - show changes between backups or a backup and current directory
+  verify       - check backups for corruption
+  reindex      - rebuild backup catalog from archives on disk
+  prune        - remove old backups according to retention rules
+  watch        - create backups automatically while files change
+  schedule     - manage scheduled backups of a project
+  daemon       - run scheduled backups in the background
+  pin          - protect backup from pruning
+  check-ignore - show whether paths are excluded from backups
+  config       - view and change project or global settings
+  encryption   - enable or disable backup encryption
+
+Backups are stored in %APPDATA%/ProjectBackup on Windows,
+$XDG_DATA_HOME/ProjectBackup (~/.local/share/ProjectBackup) on Linux
+and ~/Library/Application Support/ProjectBackup on macOS.
+Override with the BACKUP_HOME environment variable or --backup-home flag.`,
+}
+
+var (
+	storageRoot  string
+	globalConfig = config.NewGlobalConfig()
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&storageRoot, "backup-home", "", "Backup storage directory (overrides "+config.StorageRootEnv+")")
+	rootCmd.PersistentFlags().StringVar(&keyFile, "key-file", "", "File with encryption passphrase (overrides "+passphraseEnv+")")
+	cobra.OnInitialize(func() {
+		config.SetStorageRoot(storageRoot)
+		backup.SetPassphraseProvider(readPassphrase)
+
+		if loaded, err := config.LoadGlobalConfig(); err == nil {
+			globalConfig = loaded
+		}
+		if globalConfig.UI.NoColor {
+			ui.DisableColor()
+		}
+
+		warnMissingBackupPath()
+	})
+}
+
+func warnMissingBackupPath() {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil || projectConfig.MissingBackupPath() == "" {
+		return
+	}
+
+	fmt.Println(ui.Warning(fmt.Sprintf("Recorded backup path %s does not exist, using %s", projectConfig.MissingBackupPath(), projectConfig.BackupPath)))
+	fmt.Println(ui.Hint("Move the old backups there and run 'backup reindex' to record the new path, or fix backup_path in " + config.ConfigFileName))
+	fmt.Println()
 }
 
 func Execute() {
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/cron"
+	"backup-tool/internal/daemon"
+	"backup-tool/internal/ui"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage scheduled backups of this project",
+	Long: `The schedule command manages when 'backup daemon' backs up this project.
+
+A schedule is either a cron expression with five fields
+(minute hour day-of-month month day-of-week), a descriptor
+(@hourly, @daily, @weekly, @monthly, @yearly) or an interval
+such as 6h, 90m or "@every 2h".
+
+Examples:
+  backup schedule add "0 3 * * *"      every day at 03:00
+  backup schedule add "30 18 * * 1-5"  weekdays at 18:30
+  backup schedule add 4h               every 4 hours
+  backup schedule remove 1`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show schedules and recent runs",
+	Args:  cobra.NoArgs,
+	Run:   runScheduleList,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <cron expression | interval>",
+	Short: "Add a schedule",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runScheduleAdd,
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <id | number>",
+	Short: "Remove a schedule",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleRemove,
+}
+
+var scheduleRuns int
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleListCmd, scheduleAddCmd, scheduleRemoveCmd)
+	scheduleListCmd.Flags().IntVar(&scheduleRuns, "runs", 10, "Number of recent runs to show")
+}
+
+func loadScheduleProject() (string, *config.ProjectConfig, bool) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return "", nil, false
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return "", nil, false
+	}
+
+	return currentDir, projectConfig, true
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) {
+	currentDir, projectConfig, ok := loadScheduleProject()
+	if !ok {
+		return
+	}
+
+	if len(projectConfig.Schedules) == 0 {
+		fmt.Println(ui.Info("No schedules. Add one with 'backup schedule add \"0 3 * * *\"'"))
+		return
+	}
+
+	history, err := backup.LoadScheduleHistory(currentDir)
+	if err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("Failed to load run history: %v", err)))
+	}
+
+	next := make(map[string]time.Time)
+	status, err := daemon.Send(daemon.CommandStatus)
+	if err == nil {
+		for _, job := range status.Jobs {
+			if job.ProjectID == projectConfig.ID {
+				next[job.ScheduleID] = job.Next
+			}
+		}
+	}
+
+	for i, entry := range projectConfig.Schedules {
+		nextRun := "daemon stopped"
+		if status != nil {
+			nextRun = "not scheduled"
+		}
+		if when, found := next[entry.ID]; found {
+			nextRun = "next " + when.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%3d. %-8s %-20s | %-21s | %s\n",
+			i+1, entry.ID, entry.Spec, nextRun, describeLastRun(backup.LastScheduleRun(history, entry.ID)))
+	}
+
+	if status == nil {
+		fmt.Println()
+		fmt.Println(ui.Hint("Daemon is not running. Start it with 'backup daemon'"))
+	}
+
+	if len(history) == 0 || scheduleRuns <= 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Info("Recent runs"))
+
+	start := max(len(history)-scheduleRuns, 0)
+	for i := len(history) - 1; i >= start; i-- {
+		run := history[i]
+		line := fmt.Sprintf("%s | %-8s | %-9s | %6.1fs",
+			run.StartedAt.Format("2006-01-02 15:04:05"), run.ScheduleID, run.Status,
+			run.FinishedAt.Sub(run.StartedAt).Seconds())
+		if run.Error != "" {
+			line += " | " + run.Error
+		}
+
+		if run.Status == backup.RunFailed {
+			fmt.Println(ui.WarningStyle.Render(line))
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+func runScheduleAdd(cmd *cobra.Command, args []string) {
+	currentDir, projectConfig, ok := loadScheduleProject()
+	if !ok {
+		return
+	}
+
+	spec := strings.Join(args, " ")
+	parsed, err := cron.Parse(spec)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Invalid schedule: %v", err)))
+		return
+	}
+
+	for _, entry := range projectConfig.Schedules {
+		if entry.Spec == spec {
+			fmt.Println(ui.Warning(fmt.Sprintf("Schedule %q already exists (%s)", spec, entry.ID)))
+			return
+		}
+	}
+
+	entry := config.Schedule{ID: uuid.New().String()[:8], Spec: spec}
+	projectConfig.Schedules = append(projectConfig.Schedules, entry)
+
+	if err := projectConfig.Save(currentDir); err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to save configuration: %v", err)))
+		return
+	}
+	if err := config.RegisterProject(projectConfig.ID, currentDir); err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to register project for the daemon: %v", err)))
+		return
+	}
+
+	fmt.Println(ui.Success("Schedule added"))
+	fmt.Println(ui.Label("ID", entry.ID))
+	fmt.Println(ui.Label("Schedule", entry.Spec))
+	fmt.Println(ui.Label("Next run", parsed.Next(time.Now()).Format("2006-01-02 15:04")))
+	notifyDaemon()
+}
+
+func runScheduleRemove(cmd *cobra.Command, args []string) {
+	currentDir, projectConfig, ok := loadScheduleProject()
+	if !ok {
+		return
+	}
+
+	index := findSchedule(projectConfig.Schedules, args[0])
+	if index < 0 {
+		fmt.Println(ui.Error(fmt.Sprintf("Schedule %q not found", args[0])))
+		return
+	}
+
+	removed := projectConfig.Schedules[index]
+	projectConfig.Schedules = append(projectConfig.Schedules[:index], projectConfig.Schedules[index+1:]...)
+
+	if err := projectConfig.Save(currentDir); err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to save configuration: %v", err)))
+		return
+	}
+	if len(projectConfig.Schedules) == 0 {
+		if err := config.UnregisterProject(projectConfig.ID); err != nil {
+			fmt.Println(ui.Warning(fmt.Sprintf("Failed to update project registry: %v", err)))
+		}
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Schedule %s (%s) removed", removed.ID, removed.Spec)))
+	notifyDaemon()
+}
+
+func findSchedule(schedules []config.Schedule, query string) int {
+	if number, err := strconv.Atoi(query); err == nil && number >= 1 && number <= len(schedules) {
+		return number - 1
+	}
+
+	found := -1
+	for i, entry := range schedules {
+		if entry.Spec == query || entry.ID == query {
+			return i
+		}
+		if strings.HasPrefix(entry.ID, query) {
+			if found >= 0 {
+				return -1
+			}
+			found = i
+		}
+	}
+	return found
+}
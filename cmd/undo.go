@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo last backup load",
+	Long: `The undo command brings back the project state saved
+automatically before the last 'backup load'.
+Current state is saved as a new pre-restore backup first,
+so undo can be undone as well.`,
+	Run: runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
+
+func runUndo(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	if _, configErr := config.LoadProjectConfig(currentDir); configErr != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	selectedBackup := backup.FindLatestTagged(backups, backup.TagPreRestore)
+	if selectedBackup == nil {
+		fmt.Println(ui.Info("Nothing to undo. Pre-restore backups are created by 'backup load'"))
+		return
+	}
+
+	fmt.Println(ui.Warning("Project will be returned to the state before the last load"))
+	fmt.Println()
+	fmt.Println(ui.Label("Saved", selectedBackup.CreatedAt.Format("2006-01-02 15:04:05")))
+	fmt.Println(ui.Label("Files", fmt.Sprintf("%d", selectedBackup.FileCount)))
+	fmt.Println()
+
+	fmt.Print(ui.ValueStyle.Render("Continue? (y/N): "))
+	var response string
+	fmt.Scanln(&response)
+
+	if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+		fmt.Println(ui.Warning("Operation cancelled"))
+		return
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	result, err := restoreWithProgress(ctx, currentDir, selectedBackup)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Printf("\n%s\n", ui.Warning("Undo cancelled, project left unchanged"))
+			return
+		}
+		fmt.Printf("\n%s\n", ui.Error(fmt.Sprintf("Undo failed: %v", err)))
+		return
+	}
+
+	fmt.Printf("\n%s\n", ui.Success("Previous project state restored!"))
+	fmt.Println()
+	fmt.Println(ui.Label("Restored files", fmt.Sprintf("%d", result.Restored)))
+	fmt.Println(ui.Label("Removed files", fmt.Sprintf("%d", result.Removed)))
+}
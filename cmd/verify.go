@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [backup]",
+	Short: "Check backups for corruption",
+	Long: `The verify command re-reads a backup and checks it against the
+checksum recorded in the catalog and the per-file manifest (SHA-256, size and mode)
+stored inside the backup. Without arguments the newest backup is checked.
+Backup can be selected by ID, ID prefix, name or number in the list.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runVerify,
+}
+
+var verifyAll bool
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVarP(&verifyAll, "all", "a", false, "Verify all backups")
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	if _, configErr := config.LoadProjectConfig(currentDir); configErr != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	backups, err := backup.LoadBackupMetadata(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to load backup list: %v", err)))
+		return
+	}
+
+	if len(backups) == 0 {
+		fmt.Println(ui.Info("No backups to verify"))
+		return
+	}
+
+	selected := backups[:1]
+	if verifyAll {
+		selected = backups
+	} else if len(args) == 1 {
+		found, findErr := backup.FindBackup(backups, args[0])
+		if findErr != nil {
+			fmt.Println(ui.Error(findErr.Error()))
+			return
+		}
+		selected = []*config.BackupMetadata{found}
+	}
+
+	corrupted := 0
+	for _, metadata := range selected {
+		result := verifyWithProgress(metadata)
+
+		if result.OK() {
+			status := fmt.Sprintf("%s: OK (%d files)", getDisplayName(metadata), result.Files)
+			if !result.HasManifest {
+				status += ", no manifest, only CRC checked"
+			}
+			fmt.Println(ui.Success(status))
+			continue
+		}
+
+		corrupted++
+		if result.KeyRequired {
+			fmt.Println(ui.Error(fmt.Sprintf("%s: LOCKED, passphrase required", getDisplayName(metadata))))
+			continue
+		}
+		fmt.Println(ui.Error(fmt.Sprintf("%s: CORRUPTED", getDisplayName(metadata))))
+		for _, problem := range result.Problems {
+			fmt.Println(ui.Removed("  " + problem))
+		}
+	}
+
+	fmt.Println()
+	if corrupted > 0 {
+		fmt.Println(ui.Error(fmt.Sprintf("%d of %d backups failed verification", corrupted, len(selected))))
+		os.Exit(1)
+	}
+	fmt.Println(ui.Success(fmt.Sprintf("%d backups verified", len(selected))))
+}
+
+func verifyWithProgress(metadata *config.BackupMetadata) *backup.VerifyResult {
+	var bar *progressbar.ProgressBar
+
+	result := backup.VerifyBackup(metadata, func(progress backup.ArchiveProgress) {
+		if bar == nil {
+			bar = newProgressBar(progress.Total, "Verifying")
+		}
+		bar.Set(progress.Current)
+	})
+
+	if bar != nil {
+		bar.Finish()
+		bar.Clear()
+	}
+
+	return result
+}
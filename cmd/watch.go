@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/ui"
+	"backup-tool/internal/watch"
+
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Create backups automatically while files change",
+	Long: `The watch command monitors the project tree and creates a backup
+once changes have settled for the quiet period (watch.quiet_seconds,
+default 30) and at least every watch.interval_minutes (default 10)
+while changes keep coming. Excluded paths are not watched.
+
+Automatic backups are tagged "auto" and skipped when nothing changed
+since the newest backup. After each one the auto_retention rules are
+applied to automatic backups only; manual backups are never touched.
+Without configured auto_retention rules the last 20 automatic backups
+and the newest one of each of the last 7 days are kept.
+
+On Linux changes are reported by the kernel (fsnotify); elsewhere,
+or with --poll, the tree is scanned every few seconds.`,
+	Run: runWatch,
+}
+
+var (
+	watchQuiet    time.Duration
+	watchInterval time.Duration
+	watchPoll     bool
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().DurationVar(&watchQuiet, "quiet", 0, "Back up after no changes for this long (default from project config)")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 0, "Back up at least this often while changes continue (default from project config)")
+	watchCmd.Flags().BoolVar(&watchPoll, "poll", false, "Scan the tree periodically instead of using filesystem events")
+}
+
+func runWatch(cmd *cobra.Command, args []string) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to get current directory: %v", err)))
+		return
+	}
+
+	projectConfig, err := config.LoadProjectConfig(currentDir)
+	if err != nil {
+		fmt.Println(ui.Error("Project not initialized. Run 'backup init' first."))
+		return
+	}
+
+	settings := projectConfig.EffectiveWatch()
+	quiet := time.Duration(settings.QuietSeconds) * time.Second
+	if cmd.Flags().Changed("quiet") {
+		quiet = watchQuiet
+	}
+	interval := time.Duration(settings.IntervalMinutes) * time.Minute
+	if cmd.Flags().Changed("interval") {
+		interval = watchInterval
+	}
+	if quiet <= 0 {
+		fmt.Println(ui.Error("Quiet period must be positive"))
+		return
+	}
+
+	watcher, err := watch.New(currentDir, backup.NewMatcher(currentDir, projectConfig), watch.Options{
+		Quiet:    quiet,
+		Interval: interval,
+		Poll:     watchPoll,
+		Ignore:   []string{projectConfig.BackupPath},
+	})
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Failed to watch project: %v", err)))
+		return
+	}
+	defer watcher.Close()
+
+	fmt.Println(ui.Info(fmt.Sprintf("Watching %s (%s)", currentDir, watcher.Method())))
+	fmt.Println(ui.Label("Quiet period", quiet.String()))
+	if interval > 0 {
+		fmt.Println(ui.Label("Interval", interval.String()))
+	}
+	fmt.Println(ui.Hint("Press Ctrl+C to stop"))
+	fmt.Println()
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	err = watcher.Run(ctx, func(ctx context.Context, changes []string) error {
+		return autoBackup(ctx, currentDir, changes)
+	})
+	if err != nil {
+		fmt.Println(ui.Error(fmt.Sprintf("Watch stopped: %v", err)))
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Info("Watch stopped"))
+}
+
+func autoBackup(ctx context.Context, projectPath string, changes []string) error {
+	stamp := time.Now().Format("15:04:05")
+
+	metadata, err := backup.CreateBackup(ctx, projectPath, backup.CreateOptions{
+		Tags:      []string{backup.TagAuto},
+		IfChanged: true,
+	}, nil)
+
+	var unchanged *backup.UnchangedError
+	if errors.As(err, &unchanged) {
+		fmt.Println(ui.Hint(fmt.Sprintf("%s  No changes to back up", stamp)))
+		return nil
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Println(ui.Error(fmt.Sprintf("%s  Auto backup failed: %v", stamp, err)))
+		}
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("%s  Auto backup created: %d changed path(s), %d files, %.2f MB",
+		stamp, len(changes), metadata.FileCount, float64(metadata.AddedSize)/(1024*1024))))
+
+	removed, freed, err := backup.PruneAutoBackups(projectPath)
+	if err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("%s  Failed to apply auto retention: %v", stamp, err)))
+		return nil
+	}
+	if removed > 0 {
+		fmt.Println(ui.Hint(fmt.Sprintf("%s  Removed %d old auto backup(s), freed %.2f MB", stamp, removed, float64(freed)/(1024*1024))))
+	}
+	return nil
+}
@@ -1,155 +1,334 @@
 package backup
 
 import (
-	"archive/zip"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"backup-tool/internal/config"
+	"backup-tool/internal/encryption"
+	"backup-tool/internal/ignore"
+
+	"github.com/google/uuid"
 )
 
-type ArchiveProgress struct {
-	Current int
-	Total   int
-	File    string
+func NewMatcher(projectPath string, projectConfig *config.ProjectConfig) *ignore.Matcher {
+	matcher := ignore.New(projectPath, ignore.Options{UseGitignore: projectConfig.UseGitignore})
+	if projectConfig.Excludes == nil {
+		matcher.AddPatterns(projectConfig.Global().DefaultExcludes, "global config")
+	} else {
+		matcher.AddPatterns(projectConfig.Excludes, "project config")
+	}
+	matcher.AddPatterns(projectConfig.ExcludesAppend, "project config (append)")
+	return matcher
 }
 
-func ShouldExclude(path string, excludePatterns []string) bool {
-	for _, pattern := range excludePatterns {
-		if strings.HasSuffix(pattern, "/") {
-			if strings.Contains(path, pattern) {
-				return true
-			}
-		} else if strings.Contains(pattern, "*") {
-			matched, _ := filepath.Match(pattern, filepath.Base(path))
-			if matched {
-				return true
-			}
-		} else if strings.Contains(path, pattern) {
-			return true
-		}
+func checkIgnorePatterns(matcher *ignore.Matcher) error {
+	invalid := matcher.Errors()
+	if len(invalid) == 0 {
+		return nil
 	}
-	return false
+
+	messages := make([]string, len(invalid))
+	for i, err := range invalid {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("исправьте шаблоны исключений: %s", strings.Join(messages, "; "))
+}
+
+func walkProject(projectPath string, matcher *ignore.Matcher, walkFn func(path string, relPath string, info os.FileInfo) error) error {
+	return walkProjectEntries(projectPath, matcher, nil, walkFn)
 }
 
-func CountFiles(rootPath string, excludePatterns []string) (int, error) {
-	count := 0
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+func walkProjectEntries(projectPath string, matcher *ignore.Matcher, excludedFn func(relPath string, info os.FileInfo, match ignore.Match), walkFn func(path string, relPath string, info os.FileInfo) error) error {
+	return filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(projectPath, path)
 		if err != nil {
 			return err
 		}
 
-		relPath, _ := filepath.Rel(rootPath, path)
-		if ShouldExclude(relPath, excludePatterns) {
+		if relPath == "." {
+			return nil
+		}
+
+		if match := matcher.MatchEntry(relPath, info.IsDir()); match.Excluded {
+			if excludedFn != nil {
+				excludedFn(relPath, info, match)
+			}
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		if !info.IsDir() {
-			count++
-		}
-		return nil
+		return walkFn(path, relPath, info)
 	})
-	return count, err
 }
 
-func CreateBackup(projectPath string, backupName string, progressCallback func(ArchiveProgress)) (*config.BackupMetadata, error) {
-	projectConfig, err := config.LoadProjectConfig(projectPath)
+func compressionLevel(compression string) (int, error) {
+	switch compression {
+	case "", config.CompressionDefault:
+		return flate.DefaultCompression, nil
+	case config.CompressionFast:
+		return flate.BestSpeed, nil
+	case config.CompressionBest:
+		return flate.BestCompression, nil
+	case config.CompressionNone:
+		return flate.NoCompression, nil
+	}
+	return 0, fmt.Errorf("неизвестный уровень сжатия: %s", compression)
+}
+
+var newBackupHeader = func() archiveHeader {
+	return archiveHeader{ID: uuid.New().String(), CreatedAt: time.Now()}
+}
+
+type CreateOptions struct {
+	Name        string
+	Storage     string
+	Format      string
+	Symlinks    string
+	Parallelism int
+	NoCache     bool
+	IfChanged   bool
+	Pinned      bool
+	Tags        []string
+}
+
+func CreateBackup(ctx context.Context, projectPath string, options CreateOptions, progressCallback func(ArchiveProgress)) (*config.BackupMetadata, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось загрузить конфигурацию проекта: %v", err)
 	}
 
-	timestamp := time.Now().Format("20060102_150405")
-	fileName := fmt.Sprintf("backup_%s", timestamp)
-	if backupName != "" {
-		fileName = fmt.Sprintf("backup_%s_%s", timestamp, backupName)
+	key, err := projectKey(projectConfig)
+	if err != nil {
+		return nil, err
 	}
-	fileName += ".zip"
 
-	backupPath := filepath.Join(projectConfig.BackupPath, fileName)
+	lock, err := lockStorage(ctx, projectConfig.BackupPath)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
 
-	totalFiles, err := CountFiles(projectPath, projectConfig.Excludes)
+	catalog, err := openCatalog(projectConfig.BackupPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить каталог бэкапов: %v", err)
+	}
+
+	storage := options.Storage
+	if storage == "" {
+		storage = projectConfig.EffectiveStorage()
+	}
+
+	header := newBackupHeader()
+	header.Name = options.Name
+	header.Tags = options.Tags
+
+	if options.Format == "" {
+		options.Format = projectConfig.EffectiveFormat()
+	}
+	if options.Symlinks == "" {
+		options.Symlinks = projectConfig.EffectiveSymlinks()
+	}
+	if err := checkSymlinkPolicy(options.Symlinks); err != nil {
+		return nil, err
+	}
+	if options.Parallelism <= 0 {
+		options.Parallelism = projectConfig.EffectiveParallelism()
+	}
+
+	scan, err := scanProject(ctx, projectPath, NewMatcher(projectPath, projectConfig), options.Symlinks)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось подсчитать файлы: %v", err)
 	}
 
-	zipFile, err := os.Create(backupPath)
+	cache := newFileCache(projectConfig.BackupPath, header.CreatedAt)
+	if !options.NoCache {
+		cache = loadFileCache(projectConfig.BackupPath, header.CreatedAt)
+	}
+
+	if options.IfChanged {
+		if latest := latestBackup(catalog); latest != nil && latest.Fingerprint != "" {
+			fingerprint, err := fingerprintProject(ctx, scan, cache)
+			if err != nil {
+				return nil, fmt.Errorf("не удалось вычислить отпечаток проекта: %v", err)
+			}
+			if fingerprint == latest.Fingerprint {
+				cache.save(cache.BackupID, cache.Compression)
+				return nil, &UnchangedError{Backup: latest}
+			}
+		}
+	}
+
+	var metadata *config.BackupMetadata
+	switch storage {
+	case "", config.StorageArchive:
+		previous := openReusableArchive(catalog, cache, options.Format, projectConfig.EffectiveCompression())
+		metadata, err = createArchive(ctx, scan, projectConfig, options, header, key, cache, previous, progressCallback)
+		previous.Close()
+	case config.StorageSnapshot:
+		metadata, err = createSnapshot(ctx, scan, projectConfig, options, header, key, cache, progressCallback)
+	default:
+		return nil, fmt.Errorf("неизвестный тип хранилища: %s", storage)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("не удалось создать архив: %v", err)
+		return nil, err
 	}
-	defer zipFile.Close()
+	metadata.Pinned = options.Pinned
 
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	catalog.Add(metadata)
+	if err := catalog.Save(); err != nil {
+		os.Remove(metadata.FilePath)
+		return nil, err
+	}
 
-	processedFiles := 0
+	cache.save(metadata.ID, projectConfig.EffectiveCompression())
 
-	err = filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	return metadata, nil
+}
 
-		relPath, err := filepath.Rel(projectPath, path)
+func createArchive(ctx context.Context, scan *projectScan, projectConfig *config.ProjectConfig, options CreateOptions, header archiveHeader, key *cipherKey, cache *fileCache, previous *reusableArchive, progressCallback func(ArchiveProgress)) (*config.BackupMetadata, error) {
+	fileName := fmt.Sprintf("backup_%s_%s%s", header.CreatedAt.Format("20060102_150405"), header.ID[:8], archiveExtension(options.Format))
+	backupPath := filepath.Join(projectConfig.BackupPath, fileName)
+
+	archiveFile, err := os.CreateTemp(projectConfig.BackupPath, "."+fileName+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать архив: %v", err)
+	}
+	tmpPath := archiveFile.Name()
+	defer os.Remove(tmpPath)
+	defer archiveFile.Close()
+
+	hasher := sha256.New()
+	var output io.Writer = io.MultiWriter(archiveFile, hasher)
+
+	var encryptor *encryption.Writer
+	if key != nil {
+		encryptor, err = encryption.NewWriter(output, key.key, key.salt)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("не удалось начать шифрование архива: %v", err)
 		}
+		output = encryptor
+	}
 
-		if relPath == "." {
-			return nil
-		}
+	newSpool := func() *spool {
+		return &spool{dir: projectConfig.BackupPath, key: key}
+	}
+	writer, err := newArchiveWriter(options.Format, output, projectConfig.EffectiveCompression(), newSpool)
+	if err != nil {
+		return nil, err
+	}
 
-		if ShouldExclude(relPath, projectConfig.Excludes) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	processedFiles := 0
+	var uncompressedSize int64
+	manifest := &archiveManifest{Version: archiveManifestVersion}
+	fingerprint := newTreeFingerprint()
 
-		if info.IsDir() {
-			return nil
-		}
+	progress := newProgressTracker(progressCallback, scan.files, scan.bytes)
+
+	prepare := func(ctx context.Context, file projectEntry) (*archiveItem, error) {
+		return prepareArchiveItem(ctx, writer, file, cache, previous, progress)
+	}
 
-		if progressCallback != nil {
-			progressCallback(ArchiveProgress{
-				Current: processedFiles,
-				Total:   totalFiles,
-				File:    relPath,
-			})
+	consume := func(file projectEntry, item *archiveItem) error {
+		if item == nil {
+			return nil
 		}
 
-		fileInArchive, err := zipWriter.Create(relPath)
-		if err != nil {
+		if item.entry.IsDir {
+			fingerprint.add(item.entry.Name, item.entry.Mode, 0, "")
+			_, err := writer.Create(item.entry)
 			return err
 		}
 
-		fileOnDisk, err := os.Open(path)
+		var err error
+		switch {
+		case item.encoded != nil:
+			err = writer.CreateEncoded(item.encoded)
+		case isSymlink(item.entry.Mode):
+			_, err = writer.Create(item.entry)
+		default:
+			err = streamArchiveItem(ctx, writer, item, progress)
+		}
 		if err != nil {
 			return err
 		}
-		defer fileOnDisk.Close()
 
-		_, err = io.Copy(fileInArchive, fileOnDisk)
-		if err != nil {
-			return err
+		if !isSymlink(item.entry.Mode) {
+			uncompressedSize += item.entry.Size
+			cache.record(item.entry.Name, file.info, item.hash)
 		}
 
+		manifest.Files = append(manifest.Files, manifestFile{
+			Path:   item.entry.Name,
+			Size:   item.entry.Size,
+			Mode:   manifestMode(item.entry.Mode),
+			SHA256: item.hash,
+		})
+		fingerprint.add(item.entry.Name, item.entry.Mode, item.entry.Size, item.hash)
+
 		processedFiles++
+		progress.finishFile()
 		return nil
-	})
+	}
+
+	release := func(item *archiveItem) {
+		if item != nil && item.encoded != nil {
+			item.encoded.data.Close()
+		}
+	}
+
+	err = runPipeline(ctx, options.Parallelism, scan.entries, prepare, consume, release)
+	progress.done()
+
+	if err == nil {
+		err = writeArchiveManifest(writer, manifest, header.CreatedAt)
+	}
 
 	if err != nil {
-		os.Remove(backupPath)
 		return nil, fmt.Errorf("ошибка при создании архива: %v", err)
 	}
 
-	zipWriter.Close()
-	zipFile.Close()
+	header.FileCount = processedFiles
+	header.UncompressedSize = uncompressedSize
+	header.Fingerprint = fingerprint.sum()
+
+	err = writer.Finish(header)
+	if err == nil && encryptor != nil {
+		err = encryptor.Close()
+	}
+	if err == nil {
+		err = archiveFile.Sync()
+	}
+	if err == nil {
+		err = archiveFile.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось завершить архив: %v", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmpPath, backupPath); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить архив: %v", err)
+	}
 
 	fileInfo, err := os.Stat(backupPath)
 	if err != nil {
@@ -157,102 +336,233 @@ func CreateBackup(projectPath string, backupName string, progressCallback func(A
 	}
 
 	metadata := &config.BackupMetadata{
-		ID:        fmt.Sprintf("%d", time.Now().Unix()),
-		Name:      backupName,
-		Size:      fileInfo.Size(),
-		CreatedAt: time.Now(),
-		FilePath:  backupPath,
+		ID:               header.ID,
+		Name:             header.Name,
+		Tags:             header.Tags,
+		Storage:          config.StorageArchive,
+		Format:           options.Format,
+		Encrypted:        key != nil,
+		Size:             fileInfo.Size(),
+		AddedSize:        fileInfo.Size(),
+		UncompressedSize: uncompressedSize,
+		FileCount:        processedFiles,
+		Checksum:         hex.EncodeToString(hasher.Sum(nil)),
+		Fingerprint:      header.Fingerprint,
+		CreatedAt:        header.CreatedAt,
+		FileName:         fileName,
+		FilePath:         backupPath,
 	}
 
 	return metadata, nil
 }
 
-func LoadBackupMetadata(projectPath string) ([]*config.BackupMetadata, error) {
-	projectConfig, err := config.LoadProjectConfig(projectPath)
-	if err != nil {
-		return nil, err
+type archiveItem struct {
+	path    string
+	entry   archiveEntry
+	encoded *encodedEntry
+	hash    string
+}
+
+func prepareArchiveItem(ctx context.Context, writer archiveWriter, file projectEntry, cache *fileCache, previous *reusableArchive, progress *progressTracker) (*archiveItem, error) {
+	entryName := filepath.ToSlash(file.relPath)
+	if isReservedEntry(entryName) {
+		return nil, nil
+	}
+
+	item := &archiveItem{
+		path: file.path,
+		entry: archiveEntry{
+			Name:    entryName,
+			Size:    file.info.Size(),
+			Mode:    file.info.Mode(),
+			ModTime: file.info.ModTime(),
+			IsDir:   file.info.IsDir(),
+		},
 	}
 
-	var backups []*config.BackupMetadata
+	switch {
+	case file.info.IsDir():
+		return item, nil
 
-	err = filepath.Walk(projectConfig.BackupPath, func(path string, info os.FileInfo, err error) error {
+	case isSymlink(file.info.Mode()):
+		progress.startFile(entryName, 0)
+
+		linkTarget, err := readLink(file.path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if filepath.Ext(path) == ".zip" && strings.HasPrefix(info.Name(), "backup_") {
-			backup := &config.BackupMetadata{
-				ID:        fmt.Sprintf("%d", info.ModTime().Unix()),
-				Size:      info.Size(),
-				CreatedAt: info.ModTime(),
-				FilePath:  path,
-			}
+		item.entry.LinkTarget = linkTarget
+		item.entry.Size = int64(len(linkTarget))
+		linkHash := sha256.Sum256([]byte(linkTarget))
+		item.hash = hex.EncodeToString(linkHash[:])
+		return item, nil
 
-			fileName := strings.TrimSuffix(info.Name(), ".zip")
-			parts := strings.Split(fileName, "_")
-			if len(parts) > 2 {
-				backup.Name = strings.Join(parts[2:], "_")
-			}
+	case !file.info.Mode().IsRegular():
+		return nil, nil
+	}
 
-			backups = append(backups, backup)
+	if hash, ok := cache.lookup(entryName, file.info); ok {
+		if previousFile := previous.lookup(entryName, hash, item.entry.Size); previousFile != nil {
+			item.encoded = &encodedEntry{entry: item.entry, data: rawZipData{file: previousFile}, crc32: previousFile.CRC32}
+			item.hash = hash
+			progress.advance(entryName, item.entry.Size, item.entry.Size, item.entry.Size)
+			return item, nil
 		}
-		return nil
-	})
+	}
+
+	if !writer.Compresses() && item.entry.Size > spoolMemoryLimit {
+		return item, nil
+	}
 
-	return backups, err
+	fileOnDisk, err := os.Open(file.path)
+	if err != nil {
+		return nil, err
+	}
+	defer fileOnDisk.Close()
+
+	content := newHashingReader(io.LimitReader(progress.reader(entryName, item.entry.Size, &contextReader{ctx: ctx, reader: fileOnDisk}), item.entry.Size))
+	encoded, err := writer.Encode(item.entry, content)
+	if err != nil {
+		return nil, err
+	}
+	if content.read != item.entry.Size {
+		encoded.data.Close()
+		return nil, fmt.Errorf("файл %s изменился во время архивации", entryName)
+	}
+
+	item.encoded = encoded
+	item.hash = hex.EncodeToString(content.hash.Sum(nil))
+	return item, nil
 }
 
-func RestoreBackup(backupPath string, targetPath string, progressCallback func(ArchiveProgress)) error {
-	reader, err := zip.OpenReader(backupPath)
+func streamArchiveItem(ctx context.Context, writer archiveWriter, item *archiveItem, progress *progressTracker) error {
+	fileOnDisk, err := os.Open(item.path)
 	if err != nil {
-		return fmt.Errorf("не удалось открыть архив: %v", err)
+		return err
 	}
-	defer reader.Close()
+	defer fileOnDisk.Close()
 
-	totalFiles := len(reader.File)
-	processedFiles := 0
+	fileInArchive, err := writer.Create(item.entry)
+	if err != nil {
+		return err
+	}
 
-	for _, file := range reader.File {
-		if progressCallback != nil {
-			progressCallback(ArchiveProgress{
-				Current: processedFiles,
-				Total:   totalFiles,
-				File:    file.Name,
-			})
-		}
+	content := newHashingReader(io.LimitReader(progress.reader(item.entry.Name, item.entry.Size, &contextReader{ctx: ctx, reader: fileOnDisk}), item.entry.Size))
+	if _, err := io.Copy(fileInArchive, content); err != nil {
+		return err
+	}
+	if content.read != item.entry.Size {
+		return fmt.Errorf("файл %s изменился во время архивации", item.entry.Name)
+	}
 
-		path := filepath.Join(targetPath, file.Name)
+	item.hash = hex.EncodeToString(content.hash.Sum(nil))
+	return nil
+}
 
-		if file.FileInfo().IsDir() {
-			os.MkdirAll(path, file.FileInfo().Mode())
-			continue
-		}
+func LoadBackupMetadata(projectPath string) ([]*config.BackupMetadata, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			return err
-		}
+	catalog, err := LoadCatalog(projectConfig.BackupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		catalog, err = rebuildLockedCatalog(projectConfig.BackupPath)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		fileReader, err := file.Open()
-		if err != nil {
+	return newestFirst(catalog), nil
+}
+
+func newestFirst(catalog *Catalog) []*config.BackupMetadata {
+	backups := append([]*config.BackupMetadata(nil), catalog.Backups...)
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups
+}
+
+func RestoreBackup(ctx context.Context, metadata *config.BackupMetadata, targetPath string, options ExtractOptions, progressCallback func(ArchiveProgress)) error {
+	if options.Symlinks == "" {
+		options.Symlinks = config.SymlinksPreserve
+	}
+	if err := checkSymlinkPolicy(options.Symlinks); err != nil {
+		return err
+	}
+
+	x := newExtractor(targetPath, options)
+
+	var err error
+	if metadata.Storage == config.StorageSnapshot {
+		err = restoreSnapshot(ctx, metadata.FilePath, x, progressCallback)
+	} else {
+		err = restoreArchive(ctx, metadata, x, progressCallback)
+	}
+	if err != nil {
+		return err
+	}
+
+	return x.finish()
+}
+
+func restoreArchive(ctx context.Context, metadata *config.BackupMetadata, x *extractor, progressCallback func(ArchiveProgress)) error {
+	reader, err := openArchive(metadata.FilePath, archiveFormat(metadata))
+	if err != nil {
+		return fmt.Errorf("не удалось открыть архив: %v", err)
+	}
+	defer reader.Close()
+
+	totalFiles := 0
+	err = reader.Walk(func(entry archiveEntry, content io.Reader) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if isReservedEntry(entry.Name) {
+			return nil
+		}
+		if !entry.IsDir {
+			totalFiles++
+		}
 
-		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode())
-		if err != nil {
-			fileReader.Close()
-			return err
+		var linkTarget string
+		if isSymlink(entry.Mode) {
+			target, err := readLinkTarget(content)
+			if err != nil {
+				return err
+			}
+			linkTarget = target
 		}
+		return x.check(entry, linkTarget)
+	})
+	if err == nil {
+		err = x.finishCheck()
+	}
+	if err != nil {
+		return err
+	}
 
-		_, err = io.Copy(targetFile, fileReader)
-		fileReader.Close()
-		targetFile.Close()
+	progress := newProgressTracker(progressCallback, totalFiles, x.checked.bytes)
 
-		if err != nil {
+	err = reader.Walk(func(entry archiveEntry, content io.Reader) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if isReservedEntry(entry.Name) {
+			return nil
+		}
+		if entry.IsDir {
+			return x.extract(entry, content)
+		}
 
-		processedFiles++
+		return extractWithProgress(x, progress, entry, &contextReader{ctx: ctx, reader: content})
+	})
+	if err != nil {
+		return err
 	}
 
+	progress.done()
 	return nil
 }
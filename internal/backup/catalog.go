@@ -0,0 +1,315 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backup-tool/internal/config"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CatalogFileName = "catalog.json"
+	catalogVersion  = 1
+)
+
+type Catalog struct {
+	Version int                      `json:"version"`
+	Backups []*config.BackupMetadata `json:"backups"`
+
+	dir     string
+	skipped []error
+}
+
+type archiveHeader struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name,omitempty"`
+	Tags             []string  `json:"tags,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	FileCount        int       `json:"file_count"`
+	UncompressedSize int64     `json:"uncompressed_size"`
+	Fingerprint      string    `json:"fingerprint,omitempty"`
+}
+
+func LoadCatalog(backupPath string) (*Catalog, error) {
+	catalog := &Catalog{Version: catalogVersion, dir: backupPath}
+
+	data, err := os.ReadFile(filepath.Join(backupPath, CatalogFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать каталог бэкапов: %v", err)
+	}
+
+	for _, b := range catalog.Backups {
+		b.FilePath = filepath.Join(backupPath, b.FileName)
+	}
+
+	return catalog, nil
+}
+
+func (c *Catalog) Save() error {
+	sort.Slice(c.Backups, func(i, j int) bool {
+		return c.Backups[i].CreatedAt.Before(c.Backups[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать каталог бэкапов: %v", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(c.dir, CatalogFileName), data); err != nil {
+		return fmt.Errorf("не удалось сохранить каталог бэкапов: %v", err)
+	}
+
+	return nil
+}
+
+func (c *Catalog) Add(metadata *config.BackupMetadata) {
+	c.Backups = append(c.Backups, metadata)
+}
+
+func (c *Catalog) Remove(id string) bool {
+	for i, b := range c.Backups {
+		if b.ID == id {
+			c.Backups = append(c.Backups[:i], c.Backups[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Catalog) Find(id string) *config.BackupMetadata {
+	for _, b := range c.Backups {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func openCatalog(backupPath string) (*Catalog, error) {
+	catalog, err := LoadCatalog(backupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return rebuildCatalog(backupPath, nil)
+	}
+	return catalog, err
+}
+
+func rebuildLockedCatalog(backupPath string) (*Catalog, error) {
+	lock, err := lockStorage(context.Background(), backupPath)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	return openCatalog(backupPath)
+}
+
+func ReindexCatalog(projectPath string) ([]*config.BackupMetadata, []error, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lock, err := lockStorage(context.Background(), projectConfig.BackupPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer lock.Unlock()
+
+	previous, _ := LoadCatalog(projectConfig.BackupPath)
+
+	catalog, err := rebuildCatalog(projectConfig.BackupPath, previous)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return catalog.Backups, catalog.skipped, nil
+}
+
+func rebuildCatalog(backupPath string, previous *Catalog) (*Catalog, error) {
+	known := make(map[string]*config.BackupMetadata)
+	if previous != nil {
+		for _, b := range previous.Backups {
+			known[b.FileName] = b
+		}
+	}
+
+	catalog := &Catalog{Version: catalogVersion, dir: backupPath}
+
+	entries, err := os.ReadDir(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать директорию бэкапов: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || formatFromFileName(entry.Name()) == "" || !strings.HasPrefix(entry.Name(), "backup_") {
+			continue
+		}
+
+		metadata, err := inspectArchive(filepath.Join(backupPath, entry.Name()))
+		if err != nil {
+			catalog.skipped = append(catalog.skipped, fmt.Errorf("не удалось прочитать архив %s: %v", entry.Name(), err))
+			continue
+		}
+
+		catalog.Add(metadata)
+	}
+
+	snapshots, skipped, err := inspectSnapshots(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать снимки: %v", err)
+	}
+	catalog.Backups = append(catalog.Backups, snapshots...)
+	catalog.skipped = append(catalog.skipped, skipped...)
+
+	for _, metadata := range catalog.Backups {
+		if old, ok := known[metadata.FileName]; ok {
+			metadata.ID = old.ID
+			metadata.Name = old.Name
+			metadata.CreatedAt = old.CreatedAt
+			metadata.Pinned = old.Pinned
+			metadata.Tags = old.Tags
+			if old.Format != "" {
+				metadata.Format = old.Format
+			}
+		}
+	}
+
+	if err := catalog.Save(); err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+func inspectArchive(archivePath string) (*config.BackupMetadata, error) {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	format := formatFromFileName(info.Name())
+
+	reader, err := openArchive(archivePath, format)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	metadata := &config.BackupMetadata{
+		Storage:   config.StorageArchive,
+		Format:    format,
+		Encrypted: isStoredFileEncrypted(archivePath),
+		Size:      info.Size(),
+		AddedSize: info.Size(),
+		CreatedAt: info.ModTime(),
+		FileName:  info.Name(),
+		FilePath:  archivePath,
+	}
+
+	headerData := reader.Comment()
+	err = reader.Walk(func(entry archiveEntry, content io.Reader) error {
+		if entry.Name == headerEntryName {
+			data, err := io.ReadAll(content)
+			headerData = string(data)
+			return err
+		}
+		if entry.IsDir || isReservedEntry(entry.Name) {
+			return nil
+		}
+		metadata.FileCount++
+		metadata.UncompressedSize += entry.Size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if header, ok := parseArchiveHeader(headerData); ok {
+		metadata.ID = header.ID
+		metadata.Name = header.Name
+		metadata.Tags = header.Tags
+		metadata.CreatedAt = header.CreatedAt
+		metadata.Fingerprint = header.Fingerprint
+	} else {
+		metadata.ID = uuid.New().String()
+		metadata.Name, metadata.CreatedAt = parseLegacyFileName(info.Name(), info.ModTime())
+	}
+
+	checksum, err := fileChecksum(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Checksum = checksum
+
+	return metadata, nil
+}
+
+func parseLegacyFileName(fileName string, fallback time.Time) (string, time.Time) {
+	parts := strings.SplitN(strings.TrimSuffix(fileName, ".zip"), "_", 4)
+	if len(parts) < 3 {
+		return "", fallback
+	}
+
+	createdAt, err := time.ParseInLocation("20060102_150405", parts[1]+"_"+parts[2], time.Local)
+	if err != nil {
+		createdAt = fallback
+	}
+
+	name := ""
+	if len(parts) == 4 {
+		name = parts[3]
+	}
+
+	return name, createdAt
+}
+
+func fileChecksum(path string) (string, error) {
+	checksum, _, err := hashFile(path)
+	return checksum, err
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
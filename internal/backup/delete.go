@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backup-tool/internal/config"
+)
+
+func DeleteBackups(projectPath string, ids []string) (int64, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return 0, err
+	}
+
+	lock, err := lockStorage(context.Background(), projectConfig.BackupPath)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	catalog, err := openCatalog(projectConfig.BackupPath)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось загрузить каталог бэкапов: %v", err)
+	}
+
+	return deleteBackups(projectConfig.BackupPath, catalog, ids)
+}
+
+func deleteBackups(backupPath string, catalog *Catalog, ids []string) (int64, error) {
+	var removed []*config.BackupMetadata
+	for _, id := range ids {
+		metadata := catalog.Find(id)
+		if metadata == nil {
+			return 0, fmt.Errorf("бэкап %s не найден", id)
+		}
+		catalog.Remove(id)
+		removed = append(removed, metadata)
+	}
+
+	if err := catalog.Save(); err != nil {
+		return 0, err
+	}
+
+	var freed int64
+	hasSnapshots := false
+
+	for _, metadata := range removed {
+		if metadata.Storage == config.StorageSnapshot {
+			hasSnapshots = true
+		}
+
+		info, err := os.Stat(metadata.FilePath)
+		if err != nil {
+			continue
+		}
+
+		if err := os.Remove(metadata.FilePath); err != nil {
+			return freed, fmt.Errorf("не удалось удалить %s: %v", metadata.FileName, err)
+		}
+		freed += info.Size()
+	}
+
+	if hasSnapshots {
+		collected, err := collectGarbage(backupPath, catalog)
+		freed += collected
+		if err != nil {
+			return freed, fmt.Errorf("не удалось очистить неиспользуемые объекты: %v", err)
+		}
+	}
+
+	return freed, nil
+}
+
+func collectGarbage(backupPath string, catalog *Catalog) (int64, error) {
+	referenced := make(map[string]bool)
+
+	for _, metadata := range catalog.Backups {
+		if metadata.Storage != config.StorageSnapshot {
+			continue
+		}
+
+		manifest, err := readSnapshotManifest(metadata.FilePath)
+		if err != nil {
+			return 0, fmt.Errorf("не удалось прочитать снимок %s: %v", metadata.FileName, err)
+		}
+
+		for _, file := range manifest.Files {
+			referenced[file.objectName()] = true
+		}
+	}
+
+	var freed int64
+	store := newObjectStore(backupPath)
+
+	err := filepath.Walk(store.dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || referenced[info.Name()] || strings.HasSuffix(info.Name(), ".tmp") {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		freed += info.Size()
+		return nil
+	})
+
+	return freed, err
+}
@@ -0,0 +1,612 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/ignore"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+type FileChange struct {
+	Path    string
+	Type    ChangeType
+	OldSize int64
+	NewSize int64
+}
+
+func (c FileChange) SizeDelta() int64 {
+	return c.NewSize - c.OldSize
+}
+
+type Diff struct {
+	Changes []FileChange
+
+	from treeSource
+	to   treeSource
+}
+
+type DiffStat struct {
+	Added     int
+	Removed   int
+	Modified  int
+	SizeDelta int64
+}
+
+type treeEntry struct {
+	Path       string
+	Size       int64
+	Digest     string
+	Mode       os.FileMode
+	ModTime    time.Time
+	LinkTarget string
+}
+
+type treeSource interface {
+	Entries() (map[string]treeEntry, error)
+	Open(path string) (io.ReadCloser, error)
+	Close() error
+}
+
+type sequentialSource interface {
+	treeSource
+	Walk(fn func(entry treeEntry, content io.Reader) error) error
+}
+
+func DiffBackups(from, to *config.BackupMetadata) (*Diff, error) {
+	fromSource, err := openBackupSource(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toSource, err := openBackupSource(to)
+	if err != nil {
+		fromSource.Close()
+		return nil, err
+	}
+
+	return newDiff(fromSource, toSource)
+}
+
+func DiffWorkingTree(metadata *config.BackupMetadata, projectPath string, matcher *ignore.Matcher, reverse bool) (*Diff, error) {
+	backupSource, err := openBackupSource(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var dir treeSource = &dirSource{root: projectPath, matcher: matcher}
+
+	if reverse {
+		return newDiff(dir, backupSource)
+	}
+	return newDiff(backupSource, dir)
+}
+
+func newDiff(from, to treeSource) (*Diff, error) {
+	d := &Diff{from: from, to: to}
+
+	if err := d.compute(); err != nil {
+		d.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *Diff) compute() error {
+	fromEntries, err := d.from.Entries()
+	if err != nil {
+		return err
+	}
+
+	toEntries, err := d.to.Entries()
+	if err != nil {
+		return err
+	}
+
+	for path, oldEntry := range fromEntries {
+		newEntry, ok := toEntries[path]
+		if !ok {
+			d.Changes = append(d.Changes, FileChange{Path: path, Type: ChangeRemoved, OldSize: oldEntry.Size})
+			continue
+		}
+
+		same, err := d.sameContent(oldEntry, newEntry)
+		if err != nil {
+			return fmt.Errorf("не удалось сравнить %s: %v", path, err)
+		}
+
+		if !same {
+			d.Changes = append(d.Changes, FileChange{Path: path, Type: ChangeModified, OldSize: oldEntry.Size, NewSize: newEntry.Size})
+		}
+	}
+
+	for path, newEntry := range toEntries {
+		if _, ok := fromEntries[path]; !ok {
+			d.Changes = append(d.Changes, FileChange{Path: path, Type: ChangeAdded, NewSize: newEntry.Size})
+		}
+	}
+
+	sort.Slice(d.Changes, func(i, j int) bool {
+		return d.Changes[i].Path < d.Changes[j].Path
+	})
+
+	return nil
+}
+
+func (d *Diff) sameContent(oldEntry, newEntry treeEntry) (bool, error) {
+	if oldEntry.Size != newEntry.Size {
+		return false, nil
+	}
+
+	var err error
+	if oldEntry.Digest == "" && digestKind(newEntry.Digest) == "sha256" {
+		if oldEntry.Digest, err = sourceDigest(d.from, oldEntry.Path); err != nil {
+			return false, err
+		}
+	}
+	if newEntry.Digest == "" && digestKind(oldEntry.Digest) == "sha256" {
+		if newEntry.Digest, err = sourceDigest(d.to, newEntry.Path); err != nil {
+			return false, err
+		}
+	}
+
+	if oldEntry.Digest != "" && newEntry.Digest != "" && digestKind(oldEntry.Digest) == digestKind(newEntry.Digest) {
+		return oldEntry.Digest == newEntry.Digest, nil
+	}
+
+	oldReader, err := d.from.Open(oldEntry.Path)
+	if err != nil {
+		return false, err
+	}
+	defer oldReader.Close()
+
+	newReader, err := d.to.Open(newEntry.Path)
+	if err != nil {
+		return false, err
+	}
+	defer newReader.Close()
+
+	return equalReaders(oldReader, newReader)
+}
+
+func (d *Diff) Stat() DiffStat {
+	var stat DiffStat
+	for _, change := range d.Changes {
+		switch change.Type {
+		case ChangeAdded:
+			stat.Added++
+		case ChangeRemoved:
+			stat.Removed++
+		case ChangeModified:
+			stat.Modified++
+		}
+		stat.SizeDelta += change.SizeDelta()
+	}
+	return stat
+}
+
+func (d *Diff) Patch(change FileChange) (string, error) {
+	var oldData, newData []byte
+	var err error
+
+	if change.Type != ChangeAdded {
+		if oldData, err = readLimited(d.from, change.Path); err != nil {
+			return "", err
+		}
+	}
+
+	if change.Type != ChangeRemoved {
+		if newData, err = readLimited(d.to, change.Path); err != nil {
+			return "", err
+		}
+	}
+
+	if oldData == nil && change.Type != ChangeAdded || newData == nil && change.Type != ChangeRemoved {
+		return fmt.Sprintf("File %s is too large for text diff\n", change.Path), nil
+	}
+
+	if !isText(oldData) || !isText(newData) {
+		return fmt.Sprintf("Binary file %s differs\n", change.Path), nil
+	}
+
+	oldLabel, newLabel := "a/"+change.Path, "b/"+change.Path
+	switch change.Type {
+	case ChangeAdded:
+		oldLabel = "/dev/null"
+	case ChangeRemoved:
+		newLabel = "/dev/null"
+	}
+
+	return unifiedDiff(oldLabel, newLabel, string(oldData), string(newData)), nil
+}
+
+func (d *Diff) Close() error {
+	var firstErr error
+	if d.from != nil {
+		firstErr = d.from.Close()
+	}
+	if d.to != nil {
+		if err := d.to.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+const maxPatchFileSize = 1024 * 1024
+
+func readLimited(source treeSource, path string) ([]byte, error) {
+	reader, err := source.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxPatchFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxPatchFileSize {
+		return nil, nil
+	}
+
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+func sourceDigest(source treeSource, path string) (string, error) {
+	reader, err := source.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash, _, err := hashReader(reader)
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hash, nil
+}
+
+func digestKind(digest string) string {
+	if i := strings.IndexByte(digest, ':'); i >= 0 {
+		return digest[:i]
+	}
+	return ""
+}
+
+func equalReaders(a, b io.Reader) (bool, error) {
+	bufA := make([]byte, 32*1024)
+	bufB := make([]byte, 32*1024)
+
+	for {
+		n, errA := io.ReadFull(a, bufA)
+		m, errB := io.ReadFull(b, bufB[:n])
+
+		if errA != nil && errA != io.EOF && errA != io.ErrUnexpectedEOF {
+			return false, errA
+		}
+		if errB != nil && errB != io.EOF && errB != io.ErrUnexpectedEOF {
+			return false, errB
+		}
+
+		if n != m || !bytes.Equal(bufA[:n], bufB[:m]) {
+			return false, nil
+		}
+
+		if errA != nil {
+			extra, err := b.Read(bufB[:1])
+			if err != nil && err != io.EOF {
+				return false, err
+			}
+			return extra == 0, nil
+		}
+	}
+}
+
+func openBackupSource(metadata *config.BackupMetadata) (treeSource, error) {
+	if metadata.Storage == config.StorageSnapshot {
+		manifest, err := readSnapshotManifest(metadata.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать снимок: %v", err)
+		}
+		return &snapshotSource{
+			manifest: manifest,
+			store:    newObjectStore(filepath.Dir(filepath.Dir(metadata.FilePath))),
+		}, nil
+	}
+
+	format := archiveFormat(metadata)
+	if format == config.FormatTarGz || format == config.FormatTarZst {
+		reader, err := openArchive(metadata.FilePath, format)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось открыть архив: %v", err)
+		}
+		return &tarSource{reader: reader}, nil
+	}
+
+	reader, err := openZipArchive(metadata.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть архив: %v", err)
+	}
+
+	return &archiveSource{reader: reader}, nil
+}
+
+type archiveSource struct {
+	reader *zipArchive
+	files  map[string]*zip.File
+}
+
+func (s *archiveSource) Entries() (map[string]treeEntry, error) {
+	entries := make(map[string]treeEntry)
+	s.files = make(map[string]*zip.File)
+
+	hashes := make(map[string]string)
+	for _, file := range s.reader.File {
+		if file.Name != manifestEntryName {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := readArchiveManifest(reader)
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("манифест архива повреждён: %v", err)
+		}
+		for _, entry := range manifest.Files {
+			hashes[entry.Path] = entry.SHA256
+		}
+	}
+
+	for _, file := range s.reader.File {
+		if file.FileInfo().IsDir() || isReservedEntry(file.Name) {
+			continue
+		}
+
+		path := normalizeEntryName(file.Name)
+		s.files[path] = file
+
+		digest := fmt.Sprintf("crc32:%08x", file.CRC32)
+		if hash, ok := hashes[path]; ok {
+			digest = "sha256:" + hash
+		}
+
+		entry := treeEntry{
+			Path:   path,
+			Size:   int64(file.UncompressedSize64),
+			Digest: digest,
+			Mode:   zipEntryMode(file),
+		}
+		if file.ModifiedDate != 0 {
+			entry.ModTime = file.Modified
+		}
+		if isSymlink(entry.Mode) {
+			linkTarget, err := readZipLink(file)
+			if err != nil {
+				return nil, err
+			}
+			entry.LinkTarget = linkTarget
+		}
+		entries[path] = entry
+	}
+
+	return entries, nil
+}
+
+func readZipLink(file *zip.File) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	return readLinkTarget(reader)
+}
+
+func (s *archiveSource) Open(path string) (io.ReadCloser, error) {
+	file, ok := s.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return file.Open()
+}
+
+func (s *archiveSource) Close() error {
+	return s.reader.Close()
+}
+
+var errEntryFound = errors.New("entry found")
+
+type tarSource struct {
+	reader archiveReader
+}
+
+func (s *tarSource) Entries() (map[string]treeEntry, error) {
+	entries := make(map[string]treeEntry)
+
+	err := s.Walk(func(entry treeEntry, content io.Reader) error {
+		if isSymlink(entry.Mode) {
+			linkTarget, err := readLinkTarget(content)
+			if err != nil {
+				return err
+			}
+			entry.LinkTarget = linkTarget
+			content = strings.NewReader(linkTarget)
+		}
+
+		hash, size, err := hashReader(content)
+		if err != nil {
+			return err
+		}
+		entry.Size = size
+		entry.Digest = "sha256:" + hash
+		entries[entry.Path] = entry
+		return nil
+	})
+
+	return entries, err
+}
+
+func (s *tarSource) Walk(fn func(entry treeEntry, content io.Reader) error) error {
+	return s.reader.Walk(func(entry archiveEntry, content io.Reader) error {
+		if entry.IsDir || isReservedEntry(entry.Name) {
+			return nil
+		}
+		return fn(treeEntry{Path: entry.Name, Size: entry.Size, Mode: entry.Mode, ModTime: entry.ModTime}, content)
+	})
+}
+
+func (s *tarSource) Open(path string) (io.ReadCloser, error) {
+	spooled, err := os.CreateTemp("", "backup-entry-*")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Walk(func(entry treeEntry, content io.Reader) error {
+		if entry.Path != path {
+			return nil
+		}
+		if _, err := io.Copy(spooled, content); err != nil {
+			return err
+		}
+		return errEntryFound
+	})
+	if err == nil {
+		err = os.ErrNotExist
+	}
+	if errors.Is(err, errEntryFound) {
+		_, err = spooled.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		os.Remove(spooled.Name())
+		return nil, err
+	}
+
+	return &spooledFile{File: spooled}, nil
+}
+
+func (s *tarSource) Close() error {
+	return s.reader.Close()
+}
+
+type spooledFile struct {
+	*os.File
+}
+
+func (f *spooledFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+type snapshotSource struct {
+	manifest *snapshotManifest
+	store    *objectStore
+	files    map[string]snapshotFile
+}
+
+func (s *snapshotSource) Entries() (map[string]treeEntry, error) {
+	entries := make(map[string]treeEntry, len(s.manifest.Files))
+	s.files = make(map[string]snapshotFile, len(s.manifest.Files))
+
+	for _, file := range s.manifest.Files {
+		entry := file.entry()
+		s.files[file.Path] = file
+		entries[file.Path] = treeEntry{
+			Path:       file.Path,
+			Size:       file.Size,
+			Digest:     "sha256:" + file.Hash,
+			Mode:       entry.Mode,
+			ModTime:    entry.ModTime,
+			LinkTarget: file.Link,
+		}
+	}
+
+	return entries, nil
+}
+
+func (s *snapshotSource) Open(path string) (io.ReadCloser, error) {
+	file, ok := s.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	if file.Link != "" {
+		return io.NopCloser(strings.NewReader(file.Link)), nil
+	}
+	return s.store.open(file.objectName())
+}
+
+func (s *snapshotSource) Close() error {
+	return nil
+}
+
+type dirSource struct {
+	root    string
+	matcher *ignore.Matcher
+}
+
+func (s *dirSource) Entries() (map[string]treeEntry, error) {
+	entries := make(map[string]treeEntry)
+
+	err := walkProject(s.root, s.matcher, func(path string, relPath string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+
+		slashPath := filepath.ToSlash(relPath)
+		entry := treeEntry{Path: slashPath, Size: info.Size(), Mode: info.Mode(), ModTime: info.ModTime()}
+		if isSymlink(info.Mode()) {
+			linkTarget, err := readLink(path)
+			if err != nil {
+				return err
+			}
+			entry.Size = int64(len(linkTarget))
+		}
+		entries[slashPath] = entry
+		return nil
+	})
+
+	return entries, err
+}
+
+func (s *dirSource) Open(path string) (io.ReadCloser, error) {
+	fullPath := filepath.Join(s.root, filepath.FromSlash(path))
+
+	if info, err := os.Lstat(fullPath); err == nil && isSymlink(info.Mode()) {
+		linkTarget, err := readLink(fullPath)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(strings.NewReader(linkTarget)), nil
+	}
+
+	return os.Open(fullPath)
+}
+
+func (s *dirSource) Close() error {
+	return nil
+}
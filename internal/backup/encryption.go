@@ -0,0 +1,268 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/encryption"
+)
+
+type PassphraseFunc func(projectDir string, settings *config.EncryptionConfig) (string, error)
+
+var ErrKeyRequired = errors.New("бэкап зашифрован, нужен ключ")
+
+type cipherKey struct {
+	key  []byte
+	salt []byte
+}
+
+type keyProject struct {
+	dir        string
+	backupPath string
+	settings   *config.EncryptionConfig
+	passphrase string
+}
+
+var keyring = struct {
+	sync.Mutex
+	provider PassphraseFunc
+	projects map[string]*keyProject
+	keys     map[string][]byte
+}{projects: make(map[string]*keyProject), keys: make(map[string][]byte)}
+
+func SetPassphraseProvider(provider PassphraseFunc) {
+	keyring.Lock()
+	defer keyring.Unlock()
+	keyring.provider = provider
+}
+
+func loadProjectConfig(projectPath string) (*config.ProjectConfig, error) {
+	projectConfig, err := config.LoadProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring.Lock()
+	defer keyring.Unlock()
+
+	project, ok := keyring.projects[projectConfig.ID]
+	if !ok {
+		project = &keyProject{}
+		keyring.projects[projectConfig.ID] = project
+	}
+	project.dir = projectPath
+	project.backupPath = projectConfig.BackupPath
+	project.settings = projectConfig.Encryption
+
+	return projectConfig, nil
+}
+
+func projectForFile(path string) *keyProject {
+	for id, project := range keyring.projects {
+		if id == "" {
+			continue
+		}
+		rel, err := filepath.Rel(project.backupPath, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return project
+		}
+	}
+
+	project, ok := keyring.projects[""]
+	if !ok {
+		project = &keyProject{}
+		keyring.projects[""] = project
+	}
+	return project
+}
+
+func keyForFile(path string, salt []byte) ([]byte, error) {
+	keyring.Lock()
+	defer keyring.Unlock()
+	return keyForSalt(projectForFile(path), salt)
+}
+
+func keyForSalt(project *keyProject, salt []byte) ([]byte, error) {
+	if key, ok := keyring.keys[string(salt)]; ok {
+		return key, nil
+	}
+
+	if project.passphrase == "" {
+		if keyring.provider == nil {
+			return nil, ErrKeyRequired
+		}
+		passphrase, err := keyring.provider(project.dir, project.settings)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrKeyRequired, err)
+		}
+		project.passphrase = passphrase
+	}
+
+	key, err := encryption.DeriveKey(project.passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	keyring.keys[string(salt)] = key
+	return key, nil
+}
+
+func projectKey(projectConfig *config.ProjectConfig) (*cipherKey, error) {
+	settings := projectConfig.Encryption
+	if settings == nil || !settings.Enabled {
+		return nil, nil
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(settings.Salt)
+	if err != nil || len(salt) != encryption.SaltSize {
+		return nil, fmt.Errorf("некорректная соль шифрования в конфигурации проекта")
+	}
+
+	keyring.Lock()
+	defer keyring.Unlock()
+
+	project, ok := keyring.projects[projectConfig.ID]
+	if !ok {
+		project = &keyProject{backupPath: projectConfig.BackupPath, settings: settings}
+		keyring.projects[projectConfig.ID] = project
+	}
+
+	key, err := keyForSalt(project, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.KeyCheck != "" && encryption.KeyCheck(key) != settings.KeyCheck {
+		delete(keyring.keys, string(salt))
+		project.passphrase = ""
+		return nil, fmt.Errorf("неверный ключ шифрования")
+	}
+
+	return &cipherKey{key: key, salt: salt}, nil
+}
+
+func UnlockBackups(projectPath string, backups []*config.BackupMetadata) error {
+	if _, err := loadProjectConfig(projectPath); err != nil {
+		return err
+	}
+
+	for _, b := range backups {
+		if b.Encrypted && b.Storage == config.StorageSnapshot {
+			_, err := readSnapshotManifest(b.FilePath)
+			return err
+		}
+	}
+	return nil
+}
+
+func EnableEncryption(projectPath string, passphrase string, keyFile string, passphraseEnv string) error {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return err
+	}
+
+	salt, err := encryption.NewSalt()
+	if err != nil {
+		return err
+	}
+
+	key, err := encryption.DeriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	settings := projectConfig.Encryption
+	if settings == nil {
+		settings = &config.EncryptionConfig{}
+	}
+	settings.Enabled = true
+	settings.KeyFile = keyFile
+	settings.PassphraseEnv = passphraseEnv
+	settings.Salt = base64.StdEncoding.EncodeToString(salt)
+	settings.KeyCheck = encryption.KeyCheck(key)
+	projectConfig.Encryption = settings
+
+	return projectConfig.Save(projectPath)
+}
+
+type storedFile struct {
+	io.ReaderAt
+	size int64
+	file *os.File
+}
+
+func openStoredFile(path string) (*storedFile, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, false, err
+	}
+
+	salt, encrypted := encryption.ReadHeader(file)
+	if !encrypted {
+		return &storedFile{ReaderAt: file, size: info.Size(), file: file}, false, nil
+	}
+
+	key, err := keyForFile(path, salt)
+	if err != nil {
+		file.Close()
+		return nil, true, err
+	}
+
+	reader, err := encryption.NewReaderAt(file, info.Size(), key)
+	if err != nil {
+		file.Close()
+		return nil, true, err
+	}
+
+	return &storedFile{ReaderAt: reader, size: reader.Size(), file: file}, true, nil
+}
+
+func (f *storedFile) Size() int64 {
+	return f.size
+}
+
+func (f *storedFile) Close() error {
+	return f.file.Close()
+}
+
+func readStoredFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !encryption.IsEncrypted(data) {
+		return data, nil
+	}
+
+	salt, _ := encryption.ReadHeader(bytes.NewReader(data))
+	key, err := keyForFile(path, salt)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.Decrypt(data, key)
+}
+
+func isStoredFileEncrypted(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	_, encrypted := encryption.ReadHeader(file)
+	return encrypted
+}
@@ -0,0 +1,322 @@
+package backup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+type extractedDir struct {
+	path  string
+	entry archiveEntry
+}
+
+type extractor struct {
+	root         string
+	symlinks     string
+	checked      extractBudget
+	written      extractBudget
+	resolvedRoot string
+	safeDirs     map[string]bool
+	checkedLinks map[string]string
+	foldedNames  map[string]string
+	portability  []string
+	dirs         []extractedDir
+	links        []string
+}
+
+type ExtractOptions struct {
+	Symlinks string
+	Limits   config.RestoreLimits
+}
+
+func newExtractor(root string, options ExtractOptions) *extractor {
+	if options.Symlinks == "" {
+		options.Symlinks = config.SymlinksPreserve
+	}
+	return &extractor{
+		root:         root,
+		symlinks:     options.Symlinks,
+		checked:      extractBudget{limits: options.Limits},
+		written:      extractBudget{limits: options.Limits},
+		safeDirs:     make(map[string]bool),
+		checkedLinks: make(map[string]string),
+		foldedNames:  make(map[string]string),
+	}
+}
+
+func (x *extractor) check(entry archiveEntry, linkTarget string) error {
+	name, err := sanitizeEntryName(entry.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := x.checked.addEntry(); err != nil {
+		return err
+	}
+
+	if problem := nameProblem(name); problem != "" {
+		x.portability = append(x.portability, name+": "+problem)
+	}
+	if caseInsensitiveFS && !entry.IsDir {
+		folded := strings.ToLower(name)
+		if other, ok := x.foldedNames[folded]; ok && other != name {
+			x.portability = append(x.portability, name+": совпадает с "+other+" без учёта регистра")
+		}
+		x.foldedNames[folded] = name
+	}
+
+	switch {
+	case entry.IsDir:
+		return nil
+	case isSymlink(entry.Mode):
+		if x.symlinks == config.SymlinksSkip {
+			return nil
+		}
+		if err := checkLinkTarget(name, linkTarget); err != nil {
+			return err
+		}
+		x.checkedLinks[name] = strings.ReplaceAll(linkTarget, "\\", "/")
+		return nil
+	}
+
+	return x.checked.addBytes(entry.Size)
+}
+
+func (x *extractor) finishCheck() error {
+	names := make([]string, 0, len(x.checkedLinks))
+	for name := range x.checkedLinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !resolveArchivePath(x.checkedLinks, name, 0) {
+			return &UnsafeEntryError{Entry: name, Reason: "символическая ссылка ведёт за пределы директории через другие ссылки: " + x.checkedLinks[name]}
+		}
+	}
+
+	if len(x.portability) > 0 {
+		return &PortabilityError{Problems: x.portability}
+	}
+	return nil
+}
+
+func (x *extractor) extract(entry archiveEntry, content io.Reader) error {
+	name, err := sanitizeEntryName(entry.Name)
+	if err != nil {
+		return err
+	}
+	if err := x.written.addEntry(); err != nil {
+		return err
+	}
+
+	target := filepath.Join(x.root, filepath.FromSlash(name))
+	if err := x.ensureInside(name, target); err != nil {
+		return err
+	}
+
+	switch {
+	case entry.IsDir:
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return err
+		}
+		x.dirs = append(x.dirs, extractedDir{path: target, entry: entry})
+		return nil
+	case isSymlink(entry.Mode):
+		return x.symlink(name, target, content)
+	}
+
+	var limited io.Reader = content
+	if x.written.maxBytes() > 0 {
+		limited = io.LimitReader(content, x.written.remaining()+1)
+	}
+
+	written, err := extractFile(target, entry, limited)
+	if err != nil {
+		return err
+	}
+	if err := x.written.addBytes(written); err != nil {
+		os.Remove(target)
+		return err
+	}
+	return nil
+}
+
+func (x *extractor) ensureInside(name string, target string) error {
+	dir := filepath.Dir(target)
+	if x.safeDirs[dir] {
+		return nil
+	}
+
+	if x.resolvedRoot == "" {
+		if err := os.MkdirAll(x.root, 0755); err != nil {
+			return err
+		}
+		resolved, err := filepath.EvalSymlinks(x.root)
+		if err != nil {
+			return err
+		}
+		x.resolvedRoot = resolved
+	}
+
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil || !withinRoot(x.resolvedRoot, resolved) {
+		return &UnsafeEntryError{Entry: name, Reason: "путь проходит через символическую ссылку за пределы директории"}
+	}
+
+	if existing == dir {
+		x.safeDirs[dir] = true
+	}
+	return nil
+}
+
+func extractFile(target string, entry archiveEntry, content io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := tmp.Name()
+
+	written, err := io.Copy(tmp, content)
+	if err == nil {
+		err = tmp.Chmod(fileMode(entry.Mode))
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	if err := os.Rename(tmpPath, target); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	if !entry.ModTime.IsZero() {
+		return written, os.Chtimes(target, entry.ModTime, entry.ModTime)
+	}
+	return written, nil
+}
+
+func (x *extractor) symlink(name string, target string, content io.Reader) error {
+	linkTarget, err := readLinkTarget(content)
+	if err != nil {
+		return err
+	}
+
+	if x.symlinks == config.SymlinksSkip {
+		return nil
+	}
+
+	if err := checkLinkTarget(name, linkTarget); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Symlink(filepath.FromSlash(normalizeEntryName(linkTarget)), target); err != nil {
+		return err
+	}
+
+	if resolved, err := filepath.EvalSymlinks(target); err == nil && !withinRoot(x.resolvedRoot, resolved) {
+		os.Remove(target)
+		return &UnsafeEntryError{Entry: name, Reason: "символическая ссылка ведёт за пределы директории: " + linkTarget}
+	}
+
+	x.links = append(x.links, target)
+	return nil
+}
+
+func (x *extractor) finish() error {
+	if x.symlinks == config.SymlinksFollow {
+		for _, link := range x.links {
+			if err := x.materializeLink(link); err != nil {
+				return err
+			}
+		}
+	}
+
+	sort.Slice(x.dirs, func(i, j int) bool {
+		return strings.Count(x.dirs[i].path, string(filepath.Separator)) > strings.Count(x.dirs[j].path, string(filepath.Separator))
+	})
+
+	for _, dir := range x.dirs {
+		if err := applyDirAttributes(dir.path, dir.entry.Mode, dir.entry.ModTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *extractor) materializeLink(link string) error {
+	info, err := os.Stat(link)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(link)
+	if err != nil || !withinRoot(x.resolvedRoot, resolved) {
+		return nil
+	}
+
+	source, err := os.Open(link)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	_, err = extractFile(link, archiveEntry{Mode: info.Mode(), ModTime: info.ModTime()}, source)
+	return err
+}
+
+func applyDirAttributes(path string, mode os.FileMode, modTime time.Time) error {
+	if mode.Perm() != 0 {
+		if err := os.Chmod(path, mode.Perm()); err != nil {
+			return err
+		}
+	}
+	if !modTime.IsZero() {
+		return os.Chtimes(path, modTime, modTime)
+	}
+	return nil
+}
+
+func fileMode(mode os.FileMode) os.FileMode {
+	if mode.Perm() == 0 {
+		return 0644
+	}
+	return mode.Perm()
+}
@@ -0,0 +1,195 @@
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+const (
+	fileCacheName       = "file-cache.json"
+	fileCacheVersion    = 1
+	fileCacheModTimeGap = 2 * time.Second
+)
+
+type fileCache struct {
+	Version     int                  `json:"version"`
+	BackupID    string               `json:"backup_id,omitempty"`
+	Compression string               `json:"compression,omitempty"`
+	Files       map[string]fileState `json:"files"`
+
+	path    string
+	cutoff  time.Time
+	updated map[string]fileState
+}
+
+type fileState struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Inode   uint64 `json:"inode,omitempty"`
+	SHA256  string `json:"sha256"`
+}
+
+func newFileCache(backupPath string, createdAt time.Time) *fileCache {
+	return &fileCache{
+		Version: fileCacheVersion,
+		Files:   make(map[string]fileState),
+		path:    filepath.Join(backupPath, fileCacheName),
+		cutoff:  createdAt.Add(-fileCacheModTimeGap),
+		updated: make(map[string]fileState),
+	}
+}
+
+func loadFileCache(backupPath string, createdAt time.Time) *fileCache {
+	cache := newFileCache(backupPath, createdAt)
+
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+
+	var stored fileCache
+	if json.Unmarshal(data, &stored) != nil || stored.Version != fileCacheVersion || stored.Files == nil {
+		return cache
+	}
+
+	cache.BackupID = stored.BackupID
+	cache.Compression = stored.Compression
+	cache.Files = stored.Files
+	return cache
+}
+
+func (c *fileCache) lookup(name string, info os.FileInfo) (string, bool) {
+	state, ok := c.Files[name]
+	if !ok || state.SHA256 == "" {
+		return "", false
+	}
+	if state.Size != info.Size() || state.ModTime != info.ModTime().UnixNano() || state.Inode != fileInode(info) {
+		return "", false
+	}
+	return state.SHA256, true
+}
+
+func (c *fileCache) record(name string, info os.FileInfo, hash string) {
+	if !info.ModTime().Before(c.cutoff) {
+		return
+	}
+	c.updated[name] = fileState{
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+		Inode:   fileInode(info),
+		SHA256:  hash,
+	}
+}
+
+func (c *fileCache) save(backupID string, compression string) error {
+	data, err := json.Marshal(&fileCache{
+		Version:     fileCacheVersion,
+		BackupID:    backupID,
+		Compression: compression,
+		Files:       c.updated,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
+
+type reusableArchive struct {
+	archive *zipArchive
+	files   map[string]*zip.File
+	hashes  map[string]string
+}
+
+func openReusableArchive(catalog *Catalog, cache *fileCache, format string, compression string) *reusableArchive {
+	if cache.BackupID == "" || cache.Compression != compression {
+		return nil
+	}
+	if format != config.FormatZip && format != config.FormatZipStore {
+		return nil
+	}
+
+	metadata := catalog.Find(cache.BackupID)
+	if metadata == nil || metadata.Storage == config.StorageSnapshot || archiveFormat(metadata) != format {
+		return nil
+	}
+
+	archive, err := openZipArchive(metadata.FilePath)
+	if err != nil {
+		return nil
+	}
+
+	reusable := &reusableArchive{
+		archive: archive,
+		files:   make(map[string]*zip.File, len(archive.File)),
+		hashes:  make(map[string]string, len(archive.File)),
+	}
+	for _, file := range archive.File {
+		reusable.files[file.Name] = file
+	}
+
+	manifestFile, ok := reusable.files[manifestEntryName]
+	if !ok {
+		archive.Close()
+		return nil
+	}
+	reader, err := manifestFile.Open()
+	if err != nil {
+		archive.Close()
+		return nil
+	}
+	manifest, err := readArchiveManifest(reader)
+	reader.Close()
+	if err != nil {
+		archive.Close()
+		return nil
+	}
+
+	for _, file := range manifest.Files {
+		reusable.hashes[file.Path] = file.SHA256
+	}
+	return reusable
+}
+
+func (a *reusableArchive) lookup(name string, hash string, size int64) *zip.File {
+	if a == nil || a.hashes[name] != hash {
+		return nil
+	}
+	file, ok := a.files[name]
+	if !ok || file.Mode()&os.ModeType != 0 || int64(file.UncompressedSize64) != size {
+		return nil
+	}
+	return file
+}
+
+func (a *reusableArchive) Close() error {
+	if a == nil {
+		return nil
+	}
+	return a.archive.Close()
+}
+
+type rawZipData struct {
+	file *zip.File
+}
+
+func (d rawZipData) Size() int64 {
+	return int64(d.file.CompressedSize64)
+}
+
+func (d rawZipData) WriteTo(w io.Writer) (int64, error) {
+	reader, err := d.file.OpenRaw()
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, reader)
+}
+
+func (d rawZipData) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+
+	"backup-tool/internal/config"
+)
+
+var ErrUnchanged = errors.New("проект не изменился с последнего бэкапа")
+
+type UnchangedError struct {
+	Backup *config.BackupMetadata
+}
+
+func (e *UnchangedError) Error() string {
+	return fmt.Sprintf("проект не изменился с бэкапа %s", e.Backup.ID)
+}
+
+func (e *UnchangedError) Unwrap() error {
+	return ErrUnchanged
+}
+
+type treeFingerprint struct {
+	hash hash.Hash
+}
+
+func newTreeFingerprint() *treeFingerprint {
+	return &treeFingerprint{hash: sha256.New()}
+}
+
+func (f *treeFingerprint) add(name string, mode os.FileMode, size int64, contentHash string) {
+	if isReservedEntry(name) {
+		return
+	}
+	if mode.IsDir() {
+		size = 0
+	}
+	fmt.Fprintf(f.hash, "%s\x00%o\x00%d\x00%s\n", name, mode&(os.ModeType|os.ModePerm), size, contentHash)
+}
+
+func (f *treeFingerprint) sum() string {
+	return hex.EncodeToString(f.hash.Sum(nil))
+}
+
+func fingerprintProject(ctx context.Context, scan *projectScan, cache *fileCache) (string, error) {
+	fingerprint := newTreeFingerprint()
+
+	for _, file := range scan.entries {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		name := filepath.ToSlash(file.relPath)
+		switch {
+		case file.info.IsDir():
+			fingerprint.add(name, file.info.Mode(), 0, "")
+
+		case isSymlink(file.info.Mode()):
+			linkTarget, err := readLink(file.path)
+			if err != nil {
+				return "", err
+			}
+			linkHash := sha256.Sum256([]byte(linkTarget))
+			fingerprint.add(name, file.info.Mode(), int64(len(linkTarget)), hex.EncodeToString(linkHash[:]))
+
+		default:
+			contentHash, ok := cache.lookup(name, file.info)
+			if !ok {
+				var size int64
+				var err error
+				contentHash, size, err = hashFile(file.path)
+				if err != nil {
+					return "", err
+				}
+				if size != file.info.Size() {
+					return "", fmt.Errorf("файл %s изменился во время проверки", name)
+				}
+			}
+			cache.record(name, file.info, contentHash)
+			fingerprint.add(name, file.info.Mode(), file.info.Size(), contentHash)
+		}
+	}
+
+	return fingerprint.sum(), nil
+}
+
+func latestBackup(catalog *Catalog) *config.BackupMetadata {
+	var latest *config.BackupMetadata
+	for _, metadata := range catalog.Backups {
+		if latest == nil || metadata.CreatedAt.After(latest.CreatedAt) {
+			latest = metadata
+		}
+	}
+	return latest
+}
@@ -0,0 +1,547 @@
+package backup
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"backup-tool/internal/config"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const headerEntryName = ".backup-header.json"
+
+const (
+	zipCreatorUnix   = 3
+	zipCreatorMacOSX = 19
+
+	zipVersion20 = 20
+	zipVersion45 = 45
+
+	zipFlagDataDescriptor  = 0x8
+	zipFlagUTF8            = 0x800
+	zipExtendedTimestampID = 0x5455
+)
+
+type archiveEntry struct {
+	Name       string
+	Size       int64
+	Mode       os.FileMode
+	ModTime    time.Time
+	IsDir      bool
+	LinkTarget string
+}
+
+type archiveWriter interface {
+	Create(entry archiveEntry) (io.Writer, error)
+	Compresses() bool
+	Encode(entry archiveEntry, content io.Reader) (*encodedEntry, error)
+	CreateEncoded(encoded *encodedEntry) error
+	Finish(header archiveHeader) error
+}
+
+type encodedEntry struct {
+	entry archiveEntry
+	data  encodedData
+	crc32 uint32
+}
+
+type encodedData interface {
+	io.WriterTo
+	Size() int64
+	Close() error
+}
+
+type archiveReader interface {
+	Comment() string
+	Walk(fn func(entry archiveEntry, content io.Reader) error) error
+	Close() error
+}
+
+func archiveExtension(format string) string {
+	switch format {
+	case config.FormatTarGz:
+		return ".tar.gz"
+	case config.FormatTarZst:
+		return ".tar.zst"
+	}
+	return ".zip"
+}
+
+func formatFromFileName(fileName string) string {
+	switch {
+	case strings.HasSuffix(fileName, ".zip"):
+		return config.FormatZip
+	case strings.HasSuffix(fileName, ".tar.gz"):
+		return config.FormatTarGz
+	case strings.HasSuffix(fileName, ".tar.zst"):
+		return config.FormatTarZst
+	}
+	return ""
+}
+
+func archiveFormat(metadata *config.BackupMetadata) string {
+	if metadata.Format != "" {
+		return metadata.Format
+	}
+	if format := formatFromFileName(metadata.FileName); format != "" {
+		return format
+	}
+	return config.FormatZip
+}
+
+func normalizeEntryName(name string) string {
+	return strings.ReplaceAll(name, "\\", "/")
+}
+
+func isReservedEntry(name string) bool {
+	return name == manifestEntryName || name == headerEntryName
+}
+
+func newArchiveWriter(format string, w io.Writer, compression string, newSpool func() *spool) (archiveWriter, error) {
+	level, err := compressionLevel(compression)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "", config.FormatZip, config.FormatZipStore:
+		method := zip.Deflate
+		if format == config.FormatZipStore || level == flate.NoCompression {
+			method = zip.Store
+		}
+
+		zipWriter := zip.NewWriter(w)
+		zipWriter.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		})
+
+		archive := &zipArchiveWriter{writer: zipWriter, method: method, newSpool: newSpool}
+		archive.compressors.New = func() any {
+			compressor, _ := flate.NewWriter(io.Discard, level)
+			return compressor
+		}
+		return archive, nil
+
+	case config.FormatTarGz:
+		compressor, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchiveWriter{writer: tar.NewWriter(compressor), compressor: compressor, newSpool: newSpool}, nil
+
+	case config.FormatTarZst:
+		compressor, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel(compression)))
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchiveWriter{writer: tar.NewWriter(compressor), compressor: compressor, newSpool: newSpool}, nil
+	}
+
+	return nil, fmt.Errorf("неизвестный формат архива: %s", format)
+}
+
+func zstdLevel(compression string) zstd.EncoderLevel {
+	switch compression {
+	case config.CompressionFast, config.CompressionNone:
+		return zstd.SpeedFastest
+	case config.CompressionBest:
+		return zstd.SpeedBestCompression
+	}
+	return zstd.SpeedDefault
+}
+
+type zipArchiveWriter struct {
+	writer      *zip.Writer
+	method      uint16
+	compressors sync.Pool
+	newSpool    func() *spool
+}
+
+func (w *zipArchiveWriter) header(entry archiveEntry) *zip.FileHeader {
+	header := &zip.FileHeader{
+		Name:     entry.Name,
+		Method:   w.method,
+		Modified: entry.ModTime,
+	}
+	header.SetMode(entry.Mode)
+
+	if entry.IsDir {
+		header.Name = strings.TrimSuffix(entry.Name, "/") + "/"
+		header.Method = zip.Store
+	}
+	return header
+}
+
+func (w *zipArchiveWriter) Create(entry archiveEntry) (io.Writer, error) {
+	writer, err := w.writer.CreateHeader(w.header(entry))
+	if err != nil {
+		return nil, err
+	}
+
+	if isSymlink(entry.Mode) {
+		if _, err := io.WriteString(writer, entry.LinkTarget); err != nil {
+			return nil, err
+		}
+		return io.Discard, nil
+	}
+	return writer, nil
+}
+
+func (w *zipArchiveWriter) Compresses() bool {
+	return w.method == zip.Deflate
+}
+
+func (w *zipArchiveWriter) Encode(entry archiveEntry, content io.Reader) (*encodedEntry, error) {
+	data := w.newSpool()
+	encoded := &encodedEntry{entry: entry, data: data}
+	checksum := crc32.NewIEEE()
+
+	var size int64
+	var err error
+	if w.method == zip.Deflate {
+		compressor := w.compressors.Get().(*flate.Writer)
+		compressor.Reset(data)
+		size, err = io.Copy(compressor, io.TeeReader(content, checksum))
+		if err == nil {
+			err = compressor.Close()
+		}
+		w.compressors.Put(compressor)
+	} else {
+		size, err = io.Copy(data, io.TeeReader(content, checksum))
+	}
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+
+	encoded.entry.Size = size
+	encoded.crc32 = checksum.Sum32()
+	return encoded, nil
+}
+
+func (w *zipArchiveWriter) CreateEncoded(encoded *encodedEntry) error {
+	header := w.header(encoded.entry)
+	header.Flags |= zipFlagDataDescriptor
+	if zipRequiresUTF8(header.Name) {
+		header.Flags |= zipFlagUTF8
+	}
+	header.CreatorVersion = header.CreatorVersion&0xff00 | zipVersion20
+	header.ReaderVersion = zipVersion20
+	header.ModifiedDate, header.ModifiedTime = zipDosTime(header.Modified)
+	header.Extra = zipTimestampExtra(header.Modified)
+
+	header.CRC32 = encoded.crc32
+	header.CompressedSize64 = uint64(encoded.data.Size())
+	header.UncompressedSize64 = uint64(encoded.entry.Size)
+	if header.CompressedSize64 > math.MaxUint32 || header.UncompressedSize64 > math.MaxUint32 {
+		header.ReaderVersion = zipVersion45
+	}
+
+	writer, err := w.writer.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	_, err = encoded.data.WriteTo(writer)
+	return err
+}
+
+func zipRequiresUTF8(name string) bool {
+	require := false
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		i += size
+		if r < 0x20 || r > 0x7d || r == 0x5c {
+			if r == utf8.RuneError && size == 1 {
+				return false
+			}
+			require = true
+		}
+	}
+	return require
+}
+
+func zipDosTime(t time.Time) (uint16, uint16) {
+	date := uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock := uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, clock
+}
+
+func zipTimestampExtra(t time.Time) []byte {
+	extra := make([]byte, 9)
+	binary.LittleEndian.PutUint16(extra[0:], zipExtendedTimestampID)
+	binary.LittleEndian.PutUint16(extra[2:], 5)
+	extra[4] = 1
+	binary.LittleEndian.PutUint32(extra[5:], uint32(t.Unix()))
+	return extra
+}
+
+func (w *zipArchiveWriter) Finish(header archiveHeader) error {
+	comment, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать заголовок архива: %v", err)
+	}
+
+	if err := w.writer.SetComment(string(comment)); err != nil {
+		return fmt.Errorf("не удалось записать заголовок архива: %v", err)
+	}
+
+	return w.writer.Close()
+}
+
+type tarArchiveWriter struct {
+	writer     *tar.Writer
+	compressor io.WriteCloser
+	newSpool   func() *spool
+}
+
+func (w *tarArchiveWriter) Create(entry archiveEntry) (io.Writer, error) {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Name,
+		Size:     entry.Size,
+		Mode:     int64(entry.Mode.Perm()),
+		ModTime:  entry.ModTime,
+		Format:   tar.FormatPAX,
+	}
+
+	switch {
+	case entry.IsDir:
+		header.Typeflag = tar.TypeDir
+		header.Name = strings.TrimSuffix(entry.Name, "/") + "/"
+		header.Size = 0
+	case isSymlink(entry.Mode):
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = entry.LinkTarget
+		header.Size = 0
+	}
+
+	if err := w.writer.WriteHeader(header); err != nil {
+		return nil, err
+	}
+
+	if header.Typeflag != tar.TypeReg {
+		return io.Discard, nil
+	}
+	return w.writer, nil
+}
+
+func (w *tarArchiveWriter) Compresses() bool {
+	return false
+}
+
+func (w *tarArchiveWriter) Encode(entry archiveEntry, content io.Reader) (*encodedEntry, error) {
+	data := w.newSpool()
+	encoded := &encodedEntry{entry: entry, data: data}
+	size, err := io.Copy(data, content)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	encoded.entry.Size = size
+	return encoded, nil
+}
+
+func (w *tarArchiveWriter) CreateEncoded(encoded *encodedEntry) error {
+	writer, err := w.Create(encoded.entry)
+	if err != nil {
+		return err
+	}
+	_, err = encoded.data.WriteTo(writer)
+	return err
+}
+
+func (w *tarArchiveWriter) Finish(header archiveHeader) error {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать заголовок архива: %v", err)
+	}
+
+	writer, err := w.Create(archiveEntry{Name: headerEntryName, Size: int64(len(data)), Mode: 0644, ModTime: header.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("не удалось записать заголовок архива: %v", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("не удалось записать заголовок архива: %v", err)
+	}
+
+	if err := w.writer.Close(); err != nil {
+		return err
+	}
+	return w.compressor.Close()
+}
+
+func openArchive(path string, format string) (archiveReader, error) {
+	switch format {
+	case "", config.FormatZip, config.FormatZipStore:
+		return openZipArchive(path)
+	case config.FormatTarGz, config.FormatTarZst:
+		file, _, err := openStoredFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchive{file: file, format: format}, nil
+	}
+	return nil, fmt.Errorf("неизвестный формат архива: %s", format)
+}
+
+type zipArchive struct {
+	*zip.Reader
+	file *storedFile
+}
+
+func openZipArchive(path string) (*zipArchive, error) {
+	file, _, err := openStoredFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(file, file.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &zipArchive{Reader: reader, file: file}, nil
+}
+
+func (a *zipArchive) Comment() string {
+	return a.Reader.Comment
+}
+
+func (a *zipArchive) Walk(fn func(entry archiveEntry, content io.Reader) error) error {
+	for _, file := range a.File {
+		entry := archiveEntry{
+			Name:  normalizeEntryName(file.Name),
+			Size:  int64(file.UncompressedSize64),
+			Mode:  zipEntryMode(file),
+			IsDir: file.FileInfo().IsDir(),
+		}
+		if file.ModifiedDate != 0 {
+			entry.ModTime = file.Modified
+		}
+
+		if entry.IsDir {
+			if err := fn(entry, bytes.NewReader(nil)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = fn(entry, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func zipEntryMode(file *zip.File) os.FileMode {
+	switch file.CreatorVersion >> 8 {
+	case zipCreatorUnix, zipCreatorMacOSX:
+		return file.Mode()
+	}
+	if file.FileInfo().IsDir() {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (a *zipArchive) Close() error {
+	return a.file.Close()
+}
+
+type tarArchive struct {
+	file   *storedFile
+	format string
+}
+
+func (a *tarArchive) Comment() string {
+	return ""
+}
+
+func (a *tarArchive) Walk(fn func(entry archiveEntry, content io.Reader) error) error {
+	stream := io.NewSectionReader(a.file, 0, a.file.Size())
+
+	var decompressed io.Reader
+	switch a.format {
+	case config.FormatTarGz:
+		reader, err := gzip.NewReader(stream)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		decompressed = reader
+	case config.FormatTarZst:
+		reader, err := zstd.NewReader(stream)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		decompressed = reader
+	}
+
+	tarReader := tar.NewReader(decompressed)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := archiveEntry{
+			Name:    normalizeEntryName(header.Name),
+			Size:    header.Size,
+			Mode:    header.FileInfo().Mode(),
+			ModTime: header.ModTime,
+			IsDir:   header.Typeflag == tar.TypeDir,
+		}
+
+		var content io.Reader = tarReader
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeDir:
+		case tar.TypeSymlink:
+			entry.Size = int64(len(header.Linkname))
+			content = strings.NewReader(header.Linkname)
+		default:
+			continue
+		}
+
+		if err := fn(entry, content); err != nil {
+			return err
+		}
+	}
+}
+
+func (a *tarArchive) Close() error {
+	return a.file.Close()
+}
+
+func parseArchiveHeader(data string) (*archiveHeader, bool) {
+	var header archiveHeader
+	if data == "" || json.Unmarshal([]byte(data), &header) != nil || header.ID == "" {
+		return nil, false
+	}
+	return &header, true
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/ignore"
+)
+
+const maxLinkTargetSize = 4096
+
+func checkSymlinkPolicy(policy string) error {
+	switch policy {
+	case config.SymlinksPreserve, config.SymlinksFollow, config.SymlinksSkip:
+		return nil
+	}
+	return fmt.Errorf("неизвестный режим символических ссылок: %s (preserve, follow, skip)", policy)
+}
+
+func isSymlink(mode os.FileMode) bool {
+	return mode&os.ModeSymlink != 0
+}
+
+func readLinkTarget(content io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(content, maxLinkTargetSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxLinkTargetSize {
+		return "", fmt.Errorf("слишком длинная символическая ссылка")
+	}
+	return string(data), nil
+}
+
+func readLink(path string) (string, error) {
+	linkTarget, err := os.Readlink(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(linkTarget), nil
+}
+
+type linkWalker struct {
+	matcher    *ignore.Matcher
+	symlinks   string
+	excludedFn func(relPath string, info os.FileInfo, match ignore.Match)
+	walkFn     func(path string, relPath string, info os.FileInfo) error
+	visiting   map[string]bool
+}
+
+func walkProjectLinks(projectPath string, matcher *ignore.Matcher, symlinks string, walkFn func(path string, relPath string, info os.FileInfo) error) error {
+	return walkProjectLinkEntries(projectPath, matcher, symlinks, nil, walkFn)
+}
+
+func walkProjectLinkEntries(projectPath string, matcher *ignore.Matcher, symlinks string, excludedFn func(relPath string, info os.FileInfo, match ignore.Match), walkFn func(path string, relPath string, info os.FileInfo) error) error {
+	walker := &linkWalker{
+		matcher:    matcher,
+		symlinks:   symlinks,
+		excludedFn: excludedFn,
+		walkFn:     walkFn,
+		visiting:   make(map[string]bool),
+	}
+
+	if resolved, err := filepath.EvalSymlinks(projectPath); err == nil {
+		walker.visiting[resolved] = true
+	}
+
+	return walkProjectEntries(projectPath, matcher, excludedFn, walker.visit)
+}
+
+func (w *linkWalker) visit(path string, relPath string, info os.FileInfo) error {
+	if !isSymlink(info.Mode()) {
+		return w.walkFn(path, relPath, info)
+	}
+
+	switch w.symlinks {
+	case config.SymlinksSkip:
+		return nil
+	case config.SymlinksFollow:
+		return w.follow(path, relPath)
+	}
+	return w.walkFn(path, relPath, info)
+}
+
+func (w *linkWalker) follow(path string, relPath string) error {
+	target, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	if !target.IsDir() {
+		return w.walkFn(path, relPath, target)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if w.visiting[resolved] {
+		return nil
+	}
+	w.visiting[resolved] = true
+	defer delete(w.visiting, resolved)
+
+	return filepath.Walk(resolved, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(resolved, filePath)
+		if err != nil {
+			return err
+		}
+
+		entryRel := filepath.Join(relPath, rel)
+		if match := w.matcher.MatchEntry(entryRel, info.IsDir()); match.Excluded {
+			if w.excludedFn != nil {
+				w.excludedFn(entryRel, info, match)
+			}
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return w.visit(filepath.Join(path, rel), entryRel, info)
+	})
+}
@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	lockFileName      = ".lock"
+	lockRetryInterval = 200 * time.Millisecond
+)
+
+type storageLock struct {
+	file *os.File
+}
+
+func lockStorage(ctx context.Context, backupPath string) (*storageLock, error) {
+	file, err := os.OpenFile(filepath.Join(backupPath, lockFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл блокировки: %v", err)
+	}
+
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("не удалось заблокировать директорию бэкапов: %v", err)
+		}
+		if locked {
+			return &storageLock{file: file}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func (l *storageLock) Unlock() {
+	unlockFile(l.file)
+	l.file.Close()
+}
@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+func TestLockStorageWaitsForHolder(t *testing.T) {
+	backupPath := t.TempDir()
+
+	held, err := lockStorage(context.Background(), backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockRetryInterval)
+	defer cancel()
+	if _, err := lockStorage(ctx, backupPath); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second lock while held: got %v, want deadline exceeded", err)
+	}
+
+	go func() {
+		time.Sleep(lockRetryInterval)
+		held.Unlock()
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*lockRetryInterval)
+	defer cancel()
+	lock, err := lockStorage(ctx, backupPath)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestDeleteBackupsKeepsTemporaryObjects(t *testing.T) {
+	projectPath := newTestProject(t, 10)
+
+	var ids []string
+	for range 2 {
+		metadata, err := CreateBackup(context.Background(), projectPath, CreateOptions{Storage: config.StorageSnapshot, NoCache: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, metadata.ID)
+	}
+
+	projectConfig, err := config.LoadProjectConfig(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newObjectStore(projectConfig.BackupPath)
+	tmpPath := filepath.Join(store.dir, ".object.12345.tmp")
+	if err := os.WriteFile(tmpPath, []byte("in progress"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DeleteBackups(projectPath, ids[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(tmpPath); err != nil {
+		t.Fatalf("temporary object removed by garbage collection: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+var testFormats = []string{config.FormatZip, config.FormatZipStore, config.FormatTarGz, config.FormatTarZst}
+
+func newTestProject(tb testing.TB, files int) string {
+	tb.Helper()
+
+	config.SetStorageRoot(tb.TempDir())
+	tb.Cleanup(func() { config.SetStorageRoot("") })
+
+	projectPath := tb.TempDir()
+	projectConfig := config.NewProjectConfig("parallel")
+	backupPath, err := config.GetProjectBackupPath(projectConfig.ID)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	projectConfig.BackupPath = backupPath
+	if err := projectConfig.Save(projectPath); err != nil {
+		tb.Fatal(err)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < files; i++ {
+		dir := filepath.Join(projectPath, fmt.Sprintf("dir%02d", i%8))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+
+		size := 512 + random.Intn(256*1024)
+		if i%50 == 0 {
+			size = spoolMemoryLimit + 1024
+		}
+		data := make([]byte, size)
+		random.Read(data[:min(size, 4096)])
+		for j := 4096; j < size; j += 4096 {
+			copy(data[j:], data[:4096])
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d.txt", i)), data, 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	return projectPath
+}
+
+func TestCreateBackupParallelismDoesNotChangeArchive(t *testing.T) {
+	projectPath := newTestProject(t, 120)
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	defaultHeader := newBackupHeader
+	newBackupHeader = func() archiveHeader {
+		return archiveHeader{ID: "00000000-0000-4000-8000-000000000000", CreatedAt: createdAt}
+	}
+	t.Cleanup(func() { newBackupHeader = defaultHeader })
+
+	for _, format := range testFormats {
+		t.Run(format, func(t *testing.T) {
+			var expected []byte
+			for _, jobs := range []int{1, 2, 4, 8} {
+				metadata, err := CreateBackup(context.Background(), projectPath, CreateOptions{
+					Format:      format,
+					Parallelism: jobs,
+					NoCache:     true,
+				}, nil)
+				if err != nil {
+					t.Fatalf("jobs=%d: %v", jobs, err)
+				}
+
+				data, err := os.ReadFile(metadata.FilePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := DeleteBackups(projectPath, []string{metadata.ID}); err != nil {
+					t.Fatal(err)
+				}
+
+				if jobs == 1 {
+					expected = data
+					continue
+				}
+				if !bytes.Equal(data, expected) {
+					t.Errorf("jobs=%d: archive (%d bytes) differs from jobs=1 (%d bytes)", jobs, len(data), len(expected))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreateBackup(b *testing.B) {
+	projectPath := newTestProject(b, 200)
+
+	for _, format := range []string{config.FormatZip, config.FormatTarGz, config.FormatTarZst} {
+		for _, jobs := range []int{1, 2, 4, 8} {
+			name := fmt.Sprintf("%s/jobs=%d", format, jobs)
+			if jobs == 1 {
+				name = format + "/sequential"
+			}
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					metadata, err := CreateBackup(context.Background(), projectPath, CreateOptions{
+						Format:      format,
+						Parallelism: jobs,
+						NoCache:     true,
+					}, nil)
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.StopTimer()
+					os.Remove(metadata.FilePath)
+					b.StartTimer()
+				}
+			})
+		}
+	}
+}
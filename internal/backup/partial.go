@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"backup-tool/internal/config"
+)
+
+type ConflictPolicy string
+
+const (
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictRename    ConflictPolicy = "rename"
+)
+
+type PartialRestoreOptions struct {
+	Patterns   []string
+	TargetPath string
+	Conflict   ConflictPolicy
+	Symlinks   string
+	Limits     config.RestoreLimits
+}
+
+type PartialRestoreResult struct {
+	Restored []string
+	Skipped  []string
+	Renamed  map[string]string
+}
+
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(value); policy {
+	case ConflictOverwrite, ConflictSkip, ConflictRename:
+		return policy, nil
+	}
+	return "", fmt.Errorf("неизвестный режим конфликтов: %s (overwrite, skip, rename)", value)
+}
+
+func RestorePaths(ctx context.Context, metadata *config.BackupMetadata, options PartialRestoreOptions, progressCallback func(ArchiveProgress)) (*PartialRestoreResult, error) {
+	source, err := openBackupSource(metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	entries, err := source.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for entryPath := range entries {
+		if entryPath == config.ConfigFileName {
+			continue
+		}
+		if matchesAnyPattern(entryPath, options.Patterns) {
+			matched = append(matched, entryPath)
+		}
+	}
+	sort.Strings(matched)
+
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("в бэкапе нет файлов, соответствующих %s", strings.Join(options.Patterns, ", "))
+	}
+
+	if options.Symlinks == "" {
+		options.Symlinks = config.SymlinksPreserve
+	}
+	if err := checkSymlinkPolicy(options.Symlinks); err != nil {
+		return nil, err
+	}
+
+	x := newExtractor(options.TargetPath, ExtractOptions{Symlinks: options.Symlinks, Limits: options.Limits})
+	for _, entryPath := range matched {
+		entry := entries[entryPath]
+		if err := x.check(archiveEntry{Name: entryPath, Size: entry.Size, Mode: entry.Mode}, entry.LinkTarget); err != nil {
+			return nil, err
+		}
+	}
+	if err := x.finishCheck(); err != nil {
+		return nil, err
+	}
+
+	result := &PartialRestoreResult{Renamed: make(map[string]string)}
+	progress := newProgressTracker(progressCallback, len(matched), x.checked.bytes)
+
+	restore := func(entry treeEntry, content io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entryPath := entry.Path
+		target := filepath.Join(options.TargetPath, filepath.FromSlash(entryPath))
+
+		renamed := ""
+		if _, statErr := os.Lstat(target); statErr == nil {
+			switch options.Conflict {
+			case ConflictSkip:
+				result.Skipped = append(result.Skipped, entryPath)
+				if isSymlink(entry.Mode) {
+					progress.skipFile(entryPath, 0)
+				} else {
+					progress.skipFile(entryPath, entry.Size)
+				}
+				return nil
+			case ConflictRename:
+				var err error
+				renamed, err = renameAside(target)
+				if err != nil {
+					return fmt.Errorf("не удалось переименовать %s: %v", entryPath, err)
+				}
+			}
+		}
+
+		if err := extractWithProgress(x, progress, archiveEntry{Name: entryPath, Size: entry.Size, Mode: entry.Mode, ModTime: entry.ModTime}, &contextReader{ctx: ctx, reader: content}); err != nil {
+			if renamed != "" {
+				os.Remove(target)
+				if renameErr := os.Rename(renamed, target); renameErr != nil {
+					return fmt.Errorf("не удалось восстановить %s: %v; исходный файл остался в %s", entryPath, err, renamed)
+				}
+			}
+			return fmt.Errorf("не удалось восстановить %s: %v", entryPath, err)
+		}
+		if renamed != "" {
+			result.Renamed[entryPath] = renamed
+		}
+		result.Restored = append(result.Restored, entryPath)
+		return nil
+	}
+
+	if sequential, ok := source.(sequentialSource); ok {
+		wanted := make(map[string]bool, len(matched))
+		for _, entryPath := range matched {
+			wanted[entryPath] = true
+		}
+
+		err := sequential.Walk(func(entry treeEntry, content io.Reader) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !wanted[entry.Path] {
+				return nil
+			}
+			return restore(entry, content)
+		})
+		if err == nil {
+			progress.done()
+			err = x.finish()
+		}
+		return result, err
+	}
+
+	for _, entryPath := range matched {
+		reader, err := source.Open(entryPath)
+		if err != nil {
+			return result, fmt.Errorf("не удалось восстановить %s: %v", entryPath, err)
+		}
+		err = restore(entries[entryPath], reader)
+		reader.Close()
+		if err != nil {
+			return result, err
+		}
+	}
+
+	progress.done()
+	return result, x.finish()
+}
+
+func matchesAnyPattern(entryPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(path.Clean(strings.TrimPrefix(filepath.ToSlash(pattern), "./")), "/")
+		if pattern == "." {
+			return true
+		}
+
+		if entryPath == pattern || strings.HasPrefix(entryPath, pattern+"/") {
+			return true
+		}
+
+		for candidate := entryPath; candidate != "." && candidate != "/"; candidate = path.Dir(candidate) {
+			if matched, _ := path.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func renameAside(target string) (string, error) {
+	renamed := target + ".orig"
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(renamed); os.IsNotExist(err) {
+			break
+		}
+		renamed = fmt.Sprintf("%s.orig.%d", target, i)
+	}
+
+	return renamed, os.Rename(target, renamed)
+}
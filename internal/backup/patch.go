@@ -0,0 +1,218 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	patchContext  = 3
+	maxEditLength = 2000
+)
+
+type lineOp struct {
+	kind byte
+	text string
+}
+
+func isText(data []byte) bool {
+	sample := data
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	return bytes.IndexByte(sample, 0) < 0 && utf8.Valid(data)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func diffLines(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		if d > maxEditLength {
+			return replaceAll(a, b)
+		}
+
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	return replaceAll(a, b)
+}
+
+func backtrack(a, b []string, trace [][]int) []lineOp {
+	var ops []lineOp
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		offset := d + 1
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, lineOp{kind: ' ', text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, lineOp{kind: '+', text: b[y-1]})
+				y--
+			} else {
+				ops = append(ops, lineOp{kind: '-', text: a[x-1]})
+				x--
+			}
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func replaceAll(a, b []string) []lineOp {
+	ops := make([]lineOp, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, lineOp{kind: '-', text: line})
+	}
+	for _, line := range b {
+		ops = append(ops, lineOp{kind: '+', text: line})
+	}
+	return ops
+}
+
+func unifiedDiff(oldLabel, newLabel string, oldText, newText string) string {
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldLabel, newLabel)
+
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start >= len(ops) {
+			break
+		}
+
+		hunkStart := start - patchContext
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*patchContext {
+				break
+			}
+			end = run
+		}
+
+		hunkEnd := end + patchContext
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		writeHunk(&out, ops, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []lineOp, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+
+	for _, op := range ops[start:end] {
+		out.WriteByte(op.kind)
+		out.WriteString(op.text)
+		if !strings.HasSuffix(op.text, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"os"
+	"sync"
+
+	"backup-tool/internal/encryption"
+)
+
+const (
+	spoolMemoryLimit = 4 << 20
+	pipelineDepth    = 2
+)
+
+type spool struct {
+	dir       string
+	key       *cipherKey
+	buffer    bytes.Buffer
+	file      *os.File
+	output    io.Writer
+	encryptor *encryption.Writer
+	size      int64
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	if s.file == nil && s.buffer.Len()+len(p) > spoolMemoryLimit {
+		if err := s.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.output.Write(p)
+	} else {
+		n, err = s.buffer.Write(p)
+	}
+	s.size += int64(n)
+	return n, err
+}
+
+func (s *spool) spill() error {
+	file, err := os.CreateTemp(s.dir, ".spool.*.tmp")
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.output = file
+
+	if s.key != nil {
+		s.encryptor, err = encryption.NewWriter(file, s.key.key, s.key.salt)
+		if err != nil {
+			return err
+		}
+		s.output = s.encryptor
+	}
+
+	if _, err := s.buffer.WriteTo(s.output); err != nil {
+		return err
+	}
+	s.buffer = bytes.Buffer{}
+	return nil
+}
+
+func (s *spool) Size() int64 {
+	return s.size
+}
+
+func (s *spool) WriteTo(w io.Writer) (int64, error) {
+	if s.file == nil {
+		return s.buffer.WriteTo(w)
+	}
+
+	if s.encryptor == nil {
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		return io.Copy(w, io.LimitReader(s.file, s.size))
+	}
+
+	if err := s.encryptor.Close(); err != nil {
+		return 0, err
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	reader, err := encryption.NewReaderAt(s.file, info.Size(), s.key.key)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, io.NewSectionReader(reader, 0, s.size))
+}
+
+func (s *spool) Close() error {
+	s.buffer = bytes.Buffer{}
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file = nil
+	s.output = nil
+	s.encryptor = nil
+	return err
+}
+
+type hashingReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	read   int64
+}
+
+func newHashingReader(reader io.Reader) *hashingReader {
+	return &hashingReader{reader: reader, hash: sha256.New()}
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.read += int64(n)
+	return n, err
+}
+
+func runPipeline[J any, R any](ctx context.Context, workers int, jobs []J, work func(ctx context.Context, job J) (R, error), consume func(job J, result R) error, release func(result R)) error {
+	if workers <= 1 {
+		return runSequential(ctx, jobs, work, consume, release)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type slot struct {
+		job    J
+		result R
+		err    error
+		done   chan struct{}
+	}
+
+	queue := make(chan *slot, workers*pipelineDepth)
+	tokens := make(chan struct{}, workers)
+	var running sync.WaitGroup
+
+	go func() {
+		defer close(queue)
+		for _, job := range jobs {
+			current := &slot{job: job, done: make(chan struct{})}
+			select {
+			case queue <- current:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				current.err = ctx.Err()
+				close(current.done)
+				return
+			}
+
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				defer func() { <-tokens }()
+				defer close(current.done)
+				if err := ctx.Err(); err != nil {
+					current.err = err
+					return
+				}
+				current.result, current.err = work(ctx, current.job)
+			}()
+		}
+	}()
+
+	var failure error
+	for current := range queue {
+		<-current.done
+		if failure == nil && current.err != nil {
+			failure = current.err
+		}
+		if failure == nil {
+			failure = consume(current.job, current.result)
+		}
+		if current.err == nil && release != nil {
+			release(current.result)
+		}
+		if failure != nil {
+			cancel()
+		}
+	}
+	running.Wait()
+
+	if failure != nil {
+		return failure
+	}
+	return ctx.Err()
+}
+
+func runSequential[J any, R any](ctx context.Context, jobs []J, work func(ctx context.Context, job J) (R, error), consume func(job J, result R) error, release func(result R)) error {
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result, err := work(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		err = consume(job, result)
+		if release != nil {
+			release(result)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backup-tool/internal/encryption"
+)
+
+func TestSpoolSpillsIntoStorageDirectory(t *testing.T) {
+	salt, err := encryption.NewSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := encryption.DeriveKey("secret", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("plaintext spool content "), spoolMemoryLimit/8)
+
+	for _, test := range []struct {
+		name string
+		key  *cipherKey
+	}{
+		{"plain", nil},
+		{"encrypted", &cipherKey{key: key, salt: salt}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := &spool{dir: dir, key: test.key}
+
+			for chunk := data; len(chunk) > 0; chunk = chunk[min(len(chunk), 64<<10):] {
+				if _, err := s.Write(chunk[:min(len(chunk), 64<<10)]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			spilled, err := filepath.Glob(filepath.Join(dir, ".spool.*.tmp"))
+			if err != nil || len(spilled) != 1 {
+				t.Fatalf("spilled files in storage directory: %v (%v)", spilled, err)
+			}
+			stored, err := os.ReadFile(spilled[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if leaked := bytes.Contains(stored, []byte("plaintext spool content")); leaked != (test.key == nil) {
+				t.Errorf("plaintext in spilled file: %v", leaked)
+			}
+
+			var output bytes.Buffer
+			if _, err := s.WriteTo(&output); err != nil {
+				t.Fatal(err)
+			}
+			if s.Size() != int64(len(data)) || !bytes.Equal(output.Bytes(), data) {
+				t.Errorf("spool returned %d bytes, want the %d written", output.Len(), len(data))
+			}
+
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(spilled[0]); !os.IsNotExist(err) {
+				t.Errorf("spilled file not removed: %v", err)
+			}
+		})
+	}
+}
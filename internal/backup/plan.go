@@ -0,0 +1,108 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/ignore"
+)
+
+type PlanEntry struct {
+	Path     string
+	Size     int64
+	IsDir    bool
+	Excluded bool
+	Rule     *ignore.Rule
+}
+
+type DirSize struct {
+	Path  string
+	Size  int64
+	Files int
+}
+
+type BackupPlan struct {
+	Included    []PlanEntry
+	Excluded    []PlanEntry
+	FileCount   int
+	TotalSize   int64
+	Directories []DirSize
+}
+
+func PlanBackup(ctx context.Context, projectPath string, projectConfig *config.ProjectConfig, symlinks string) (*BackupPlan, error) {
+	if symlinks == "" {
+		symlinks = projectConfig.EffectiveSymlinks()
+	}
+	if err := checkSymlinkPolicy(symlinks); err != nil {
+		return nil, err
+	}
+
+	matcher := NewMatcher(projectPath, projectConfig)
+	scan, err := scanProject(ctx, projectPath, matcher, symlinks)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &BackupPlan{FileCount: scan.files, TotalSize: scan.bytes}
+	dirs := make(map[string]*DirSize)
+
+	for _, entry := range scan.excluded {
+		plan.Excluded = append(plan.Excluded, PlanEntry{
+			Path:     filepath.ToSlash(entry.relPath),
+			Size:     fileSize(entry.info),
+			IsDir:    entry.info.IsDir(),
+			Excluded: true,
+			Rule:     entry.rule,
+		})
+	}
+
+	for _, entry := range scan.entries {
+		if entry.info.IsDir() {
+			continue
+		}
+
+		slashPath := filepath.ToSlash(entry.relPath)
+		size := fileSize(entry.info)
+		if isSymlink(entry.info.Mode()) {
+			size = 0
+		}
+		plan.Included = append(plan.Included, PlanEntry{
+			Path: slashPath,
+			Size: size,
+			Rule: matcher.MatchEntry(entry.relPath, false).Rule,
+		})
+
+		for dir := path.Dir(slashPath); dir != "."; dir = path.Dir(dir) {
+			dirSize, ok := dirs[dir]
+			if !ok {
+				dirSize = &DirSize{Path: dir}
+				dirs[dir] = dirSize
+			}
+			dirSize.Size += size
+			dirSize.Files++
+		}
+	}
+
+	for _, entry := range dirs {
+		plan.Directories = append(plan.Directories, *entry)
+	}
+	sort.Slice(plan.Directories, func(i, j int) bool {
+		if plan.Directories[i].Size != plan.Directories[j].Size {
+			return plan.Directories[i].Size > plan.Directories[j].Size
+		}
+		return plan.Directories[i].Path < plan.Directories[j].Path
+	})
+
+	return plan, nil
+}
+
+func fileSize(info os.FileInfo) int64 {
+	if info.IsDir() {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"backup-tool/internal/ignore"
+)
+
+const progressInterval = 100 * time.Millisecond
+
+type ArchiveProgress struct {
+	Current    int
+	Total      int
+	File       string
+	Bytes      int64
+	TotalBytes int64
+	FileBytes  int64
+	FileSize   int64
+	Throughput float64
+	ETA        time.Duration
+}
+
+func (p ArchiveProgress) FilePercent() int {
+	if p.FileSize <= 0 {
+		return 100
+	}
+	return int(p.FileBytes * 100 / p.FileSize)
+}
+
+type projectEntry struct {
+	path    string
+	relPath string
+	info    os.FileInfo
+}
+
+type excludedEntry struct {
+	relPath string
+	info    os.FileInfo
+	rule    *ignore.Rule
+}
+
+type projectScan struct {
+	entries  []projectEntry
+	excluded []excludedEntry
+	files    int
+	bytes    int64
+}
+
+func scanProject(ctx context.Context, projectPath string, matcher *ignore.Matcher, symlinks string) (*projectScan, error) {
+	scan := &projectScan{}
+	excludedFn := func(relPath string, info os.FileInfo, match ignore.Match) {
+		scan.excluded = append(scan.excluded, excludedEntry{relPath: relPath, info: info, rule: match.Rule})
+	}
+	err := walkProjectLinkEntries(projectPath, matcher, symlinks, excludedFn, func(path string, relPath string, info os.FileInfo) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+		case info.Mode().IsRegular():
+			scan.files++
+			scan.bytes += info.Size()
+		case isSymlink(info.Mode()):
+			scan.files++
+		default:
+			return nil
+		}
+
+		scan.entries = append(scan.entries, projectEntry{path: path, relPath: relPath, info: info})
+		return nil
+	})
+	if err == nil {
+		err = checkIgnorePatterns(matcher)
+	}
+	return scan, err
+}
+
+type progressTracker struct {
+	mu       sync.Mutex
+	callback func(ArchiveProgress)
+	progress ArchiveProgress
+	started  time.Time
+	reported time.Time
+}
+
+func newProgressTracker(callback func(ArchiveProgress), totalFiles int, totalBytes int64) *progressTracker {
+	if callback == nil {
+		return nil
+	}
+	return &progressTracker{
+		callback: callback,
+		progress: ArchiveProgress{Total: totalFiles, TotalBytes: totalBytes},
+		started:  time.Now(),
+	}
+}
+
+func (t *progressTracker) startFile(name string, size int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.File = name
+	t.progress.FileSize = size
+	t.progress.FileBytes = 0
+	t.report()
+}
+
+func (t *progressTracker) finishFile() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.Current++
+}
+
+func (t *progressTracker) skipFile(name string, size int64) {
+	if t == nil {
+		return
+	}
+	t.advance(name, size, size, size)
+	t.finishFile()
+}
+
+func (t *progressTracker) advance(name string, size int64, fileBytes int64, n int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.Bytes += n
+	t.progress.File = name
+	t.progress.FileSize = size
+	t.progress.FileBytes = fileBytes
+	if time.Since(t.reported) >= progressInterval {
+		t.report()
+	}
+}
+
+func (t *progressTracker) reader(name string, size int64, reader io.Reader) io.Reader {
+	if t == nil {
+		return reader
+	}
+	return &progressReader{tracker: t, reader: reader, name: name, size: size}
+}
+
+func (t *progressTracker) done() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.File = ""
+	t.report()
+}
+
+func (t *progressTracker) report() {
+	t.reported = time.Now()
+
+	if elapsed := t.reported.Sub(t.started).Seconds(); elapsed > 0 {
+		t.progress.Throughput = float64(t.progress.Bytes) / elapsed
+	}
+	if t.progress.Throughput > 0 && t.progress.TotalBytes > t.progress.Bytes {
+		remaining := float64(t.progress.TotalBytes-t.progress.Bytes) / t.progress.Throughput
+		t.progress.ETA = time.Duration(remaining * float64(time.Second))
+	} else {
+		t.progress.ETA = 0
+	}
+
+	t.callback(t.progress)
+}
+
+type progressReader struct {
+	tracker *progressTracker
+	reader  io.Reader
+	name    string
+	size    int64
+	read    int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	r.tracker.advance(r.name, r.size, r.read, int64(n))
+	return n, err
+}
+
+func extractWithProgress(x *extractor, progress *progressTracker, entry archiveEntry, content io.Reader) error {
+	if isSymlink(entry.Mode) {
+		progress.startFile(entry.Name, 0)
+	} else {
+		progress.startFile(entry.Name, entry.Size)
+		content = progress.reader(entry.Name, entry.Size, content)
+	}
+
+	if err := x.extract(entry, content); err != nil {
+		return err
+	}
+
+	progress.finishFile()
+	return nil
+}
@@ -0,0 +1,354 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/ignore"
+)
+
+const TagPreRestore = "pre-restore"
+
+type RestoreOptions struct {
+	CleanAll         bool
+	SkipSafetyBackup bool
+	Symlinks         string
+}
+
+type RestoreResult struct {
+	SafetyBackup *config.BackupMetadata
+	Restored     int
+	Removed      int
+}
+
+type restoreTransaction struct {
+	projectPath string
+	trashPath   string
+	trashed     []string
+	moved       []string
+	created     []string
+	keep        map[string]bool
+}
+
+type stagedTree struct {
+	files []string
+	dirs  []stagedDir
+}
+
+type stagedDir struct {
+	relPath string
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func RestoreProject(ctx context.Context, projectPath string, metadata *config.BackupMetadata, options RestoreOptions, progressCallback func(ArchiveProgress)) (*RestoreResult, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить конфигурацию проекта: %v", err)
+	}
+
+	result := &RestoreResult{}
+
+	stagingPath, err := os.MkdirTemp(filepath.Dir(projectPath), "."+filepath.Base(projectPath)+".restore-")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать промежуточную директорию: %v", err)
+	}
+	defer os.RemoveAll(stagingPath)
+
+	symlinks := options.Symlinks
+	if symlinks == "" {
+		symlinks = projectConfig.EffectiveSymlinks()
+	}
+
+	extractOptions := ExtractOptions{Symlinks: symlinks, Limits: projectConfig.EffectiveRestoreLimits()}
+	if err := RestoreBackup(ctx, metadata, stagingPath, extractOptions, progressCallback); err != nil {
+		return nil, fmt.Errorf("не удалось распаковать бэкап: %w", err)
+	}
+
+	staged, err := collectStaged(stagingPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось проверить распакованные файлы: %v", err)
+	}
+
+	if metadata.FileCount > 0 && symlinks != config.SymlinksSkip && len(staged.files) != metadata.FileCount {
+		return nil, fmt.Errorf("распаковано %d файлов вместо %d, бэкап повреждён", len(staged.files), metadata.FileCount)
+	}
+
+	if !options.SkipSafetyBackup {
+		safetyBackup, err := CreateBackup(ctx, projectPath, CreateOptions{Tags: []string{TagPreRestore}}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось сохранить текущее состояние проекта: %v", err)
+		}
+		result.SafetyBackup = safetyBackup
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	trashPath, err := os.MkdirTemp(filepath.Dir(projectPath), "."+filepath.Base(projectPath)+".rollback-")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию отката: %v", err)
+	}
+
+	tx := &restoreTransaction{projectPath: projectPath, trashPath: trashPath}
+
+	if err := tx.apply(stagingPath, staged, NewMatcher(projectPath, projectConfig), options.CleanAll); err != nil {
+		if rollbackErr := tx.rollback(); rollbackErr != nil {
+			return nil, fmt.Errorf("ошибка восстановления: %v; откат не удался: %v; исходные файлы сохранены в %s", err, rollbackErr, trashPath)
+		}
+		os.RemoveAll(trashPath)
+		return nil, fmt.Errorf("ошибка восстановления, изменения отменены: %v", err)
+	}
+
+	inBackup := make(map[string]bool, len(staged.files))
+	for _, relPath := range staged.files {
+		inBackup[relPath] = true
+	}
+	for _, relPath := range tx.trashed {
+		if !inBackup[relPath] {
+			result.Removed++
+		}
+	}
+	result.Restored = len(tx.moved)
+
+	tx.removeEmptyDirs()
+	os.RemoveAll(trashPath)
+
+	return result, nil
+}
+
+func collectStaged(stagingPath string) (*stagedTree, error) {
+	staged := &stagedTree{}
+
+	err := filepath.Walk(stagingPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(stagingPath, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		if info.IsDir() {
+			staged.dirs = append(staged.dirs, stagedDir{relPath: relPath, mode: info.Mode().Perm(), modTime: info.ModTime()})
+			return os.Chmod(path, info.Mode().Perm()|0700)
+		}
+
+		staged.files = append(staged.files, relPath)
+		return nil
+	})
+
+	return staged, err
+}
+
+func (tx *restoreTransaction) apply(stagingPath string, staged *stagedTree, matcher *ignore.Matcher, cleanAll bool) error {
+	var toTrash []string
+
+	tx.keep = make(map[string]bool, len(staged.dirs))
+	for _, dir := range staged.dirs {
+		tx.keep[filepath.Join(tx.projectPath, dir.relPath)] = true
+	}
+
+	if cleanAll {
+		entries, err := os.ReadDir(tx.projectPath)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Name() != config.ConfigFileName {
+				toTrash = append(toTrash, entry.Name())
+			}
+		}
+	} else {
+		err := walkProject(tx.projectPath, matcher, func(path string, relPath string, info os.FileInfo) error {
+			if relPath == config.ConfigFileName {
+				return nil
+			}
+
+			if !info.IsDir() {
+				toTrash = append(toTrash, relPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := checkIgnorePatterns(matcher); err != nil {
+			return err
+		}
+	}
+
+	for _, relPath := range toTrash {
+		if err := tx.moveToTrash(relPath); err != nil {
+			return err
+		}
+	}
+
+	for _, relPath := range staged.files {
+		if relPath == config.ConfigFileName {
+			continue
+		}
+
+		target := filepath.Join(tx.projectPath, relPath)
+
+		if info, err := os.Lstat(target); err == nil {
+			if info.IsDir() {
+				hasFiles, err := containsFiles(target)
+				if err != nil {
+					return err
+				}
+				if hasFiles {
+					return fmt.Errorf("%s в бэкапе является файлом, а в проекте директорией с исключёнными файлами; перенесите её вручную", relPath)
+				}
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+			} else if err := tx.moveToTrash(relPath); err != nil {
+				return err
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if err := os.Rename(filepath.Join(stagingPath, relPath), target); err != nil {
+			return err
+		}
+		tx.moved = append(tx.moved, relPath)
+	}
+
+	for _, dir := range staged.dirs {
+		target := filepath.Join(tx.projectPath, dir.relPath)
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			tx.created = append(tx.created, dir.relPath)
+		}
+	}
+
+	for i := len(staged.dirs) - 1; i >= 0; i-- {
+		dir := staged.dirs[i]
+		if err := applyDirAttributes(filepath.Join(tx.projectPath, dir.relPath), dir.mode, dir.modTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func containsFiles(dir string) (bool, error) {
+	found := false
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (tx *restoreTransaction) moveToTrash(relPath string) error {
+	trashed := filepath.Join(tx.trashPath, relPath)
+	if err := os.MkdirAll(filepath.Dir(trashed), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(filepath.Join(tx.projectPath, relPath), trashed); err != nil {
+		return err
+	}
+
+	tx.trashed = append(tx.trashed, relPath)
+	return nil
+}
+
+func (tx *restoreTransaction) rollback() error {
+	var firstErr error
+	tx.keep = nil
+
+	for i := len(tx.moved) - 1; i >= 0; i-- {
+		if err := os.Remove(filepath.Join(tx.projectPath, tx.moved[i])); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for i := len(tx.trashed) - 1; i >= 0; i-- {
+		original := filepath.Join(tx.projectPath, tx.trashed[i])
+		if err := os.MkdirAll(filepath.Dir(original), 0755); err != nil && firstErr == nil {
+			firstErr = err
+			continue
+		}
+		if err := os.Rename(filepath.Join(tx.trashPath, tx.trashed[i]), original); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for i := len(tx.created) - 1; i >= 0; i-- {
+		os.Remove(filepath.Join(tx.projectPath, tx.created[i]))
+	}
+
+	tx.removeEmptyDirs()
+	return firstErr
+}
+
+func (tx *restoreTransaction) removeEmptyDirs() {
+	touched := make(map[string]bool)
+	for _, relPath := range append(append([]string(nil), tx.trashed...), tx.moved...) {
+		touched[filepath.Dir(filepath.Join(tx.projectPath, relPath))] = true
+	}
+
+	dirs := make([]string, 0, len(touched))
+	for dir := range touched {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+
+	for _, dir := range dirs {
+		for len(dir) > len(tx.projectPath) {
+			if info, err := os.Lstat(dir); tx.keep[dir] || err != nil || !info.IsDir() || os.Remove(dir) != nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+}
+
+func FindLatestTagged(backups []*config.BackupMetadata, tag string) *config.BackupMetadata {
+	var latest *config.BackupMetadata
+	for _, b := range backups {
+		if !hasTag(b, tag) {
+			continue
+		}
+		if latest == nil || b.CreatedAt.After(latest.CreatedAt) {
+			latest = b
+		}
+	}
+	return latest
+}
+
+func hasTag(metadata *config.BackupMetadata, tag string) bool {
+	for _, t := range metadata.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+const TagAuto = "auto"
+
+type PruneDecision struct {
+	Backup *config.BackupMetadata
+	Keep   bool
+	Reason string
+}
+
+func PlanPrune(backups []*config.BackupMetadata, policy config.RetentionPolicy, now time.Time, force bool) []*PruneDecision {
+	sorted := append([]*config.BackupMetadata(nil), backups...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	decisions := make([]*PruneDecision, len(sorted))
+	protected := make([]bool, len(sorted))
+
+	for i, b := range sorted {
+		decisions[i] = &PruneDecision{Backup: b, Keep: true}
+	}
+
+	if policy.IsEmpty() {
+		for _, d := range decisions {
+			d.Reason = "no retention rules"
+		}
+		return decisions
+	}
+
+	for i, b := range sorted {
+		if force {
+			continue
+		}
+		if b.Pinned {
+			decisions[i].Reason = "pinned"
+			protected[i] = true
+		} else if b.Name != "" {
+			decisions[i].Reason = "named"
+			protected[i] = true
+		}
+	}
+
+	hasKeepRules := policy.KeepLast > 0 || policy.KeepDaily > 0 || policy.KeepWeekly > 0 || policy.KeepMonthly > 0
+	if hasKeepRules {
+		reasons := make([]string, len(sorted))
+
+		for i := 0; i < len(sorted) && i < policy.KeepLast; i++ {
+			reasons[i] = "last"
+		}
+
+		applyBucketRule(sorted, reasons, policy.KeepDaily, "daily", func(t time.Time) string {
+			return t.Format("2006-01-02")
+		})
+		applyBucketRule(sorted, reasons, policy.KeepWeekly, "weekly", func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		})
+		applyBucketRule(sorted, reasons, policy.KeepMonthly, "monthly", func(t time.Time) string {
+			return t.Format("2006-01")
+		})
+
+		for i, d := range decisions {
+			if reasons[i] != "" {
+				protected[i] = true
+			}
+			if d.Reason != "" {
+				continue
+			}
+			if reasons[i] != "" {
+				d.Reason = "keep " + reasons[i]
+			} else {
+				d.Keep = false
+				d.Reason = "not matched by any keep rule"
+			}
+		}
+	}
+
+	if policy.MaxAgeDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.MaxAgeDays)
+		for i, d := range decisions {
+			if d.Keep && !protected[i] && d.Backup.CreatedAt.Before(cutoff) {
+				d.Keep = false
+				d.Reason = fmt.Sprintf("older than %d days", policy.MaxAgeDays)
+			}
+		}
+	}
+
+	if policy.MaxTotalSizeMB > 0 {
+		limit := policy.MaxTotalSizeMB * 1024 * 1024
+
+		var total int64
+		for _, d := range decisions {
+			if d.Keep {
+				total += d.Backup.AddedSize
+			}
+		}
+
+		for i := len(decisions) - 1; i >= 0 && total > limit; i-- {
+			d := decisions[i]
+			if d.Keep && !protected[i] {
+				d.Keep = false
+				d.Reason = fmt.Sprintf("total size exceeds %d MB", policy.MaxTotalSizeMB)
+				total -= d.Backup.AddedSize
+			}
+		}
+	}
+
+	for _, d := range decisions {
+		if d.Keep && d.Reason == "" {
+			d.Reason = "within limits"
+		}
+	}
+
+	return decisions
+}
+
+func PlanRetention(backups []*config.BackupMetadata, policy config.RetentionPolicy, autoPolicy config.RetentionPolicy, now time.Time, force bool) []*PruneDecision {
+	var manual, auto []*config.BackupMetadata
+	var decisions []*PruneDecision
+	for _, b := range backups {
+		switch {
+		case hasTag(b, TagPreRestore):
+			decisions = append(decisions, &PruneDecision{Backup: b, Keep: true, Reason: "pre-restore"})
+		case hasTag(b, TagAuto):
+			auto = append(auto, b)
+		default:
+			manual = append(manual, b)
+		}
+	}
+
+	decisions = append(decisions, PlanPrune(manual, policy, now, force)...)
+	for _, d := range PlanPrune(auto, autoPolicy, now, force) {
+		d.Reason = "auto: " + d.Reason
+		decisions = append(decisions, d)
+	}
+
+	sort.SliceStable(decisions, func(i, j int) bool {
+		return decisions[i].Backup.CreatedAt.After(decisions[j].Backup.CreatedAt)
+	})
+	return decisions
+}
+
+func PruneBackups(projectPath string) (int, int64, error) {
+	return pruneProject(projectPath, func(projectConfig *config.ProjectConfig, backups []*config.BackupMetadata) []*PruneDecision {
+		return PlanRetention(backups, projectConfig.EffectiveRetention(), projectConfig.EffectiveAutoRetention(), time.Now(), false)
+	})
+}
+
+func PruneAutoBackups(projectPath string) (int, int64, error) {
+	return pruneProject(projectPath, func(projectConfig *config.ProjectConfig, backups []*config.BackupMetadata) []*PruneDecision {
+		var auto []*config.BackupMetadata
+		for _, b := range backups {
+			if hasTag(b, TagAuto) {
+				auto = append(auto, b)
+			}
+		}
+		return PlanPrune(auto, projectConfig.EffectiveAutoRetention(), time.Now(), false)
+	})
+}
+
+func pruneProject(projectPath string, plan func(*config.ProjectConfig, []*config.BackupMetadata) []*PruneDecision) (int, int64, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lock, err := lockStorage(context.Background(), projectConfig.BackupPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer lock.Unlock()
+
+	catalog, err := openCatalog(projectConfig.BackupPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("не удалось загрузить каталог бэкапов: %v", err)
+	}
+
+	var remove []string
+	for _, d := range plan(projectConfig, newestFirst(catalog)) {
+		if !d.Keep {
+			remove = append(remove, d.Backup.ID)
+		}
+	}
+	if len(remove) == 0 {
+		return 0, 0, nil
+	}
+
+	freed, err := deleteBackups(projectConfig.BackupPath, catalog, remove)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(remove), freed, nil
+}
+
+func applyBucketRule(sorted []*config.BackupMetadata, reasons []string, limit int, reason string, bucket func(time.Time) string) {
+	if limit <= 0 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i, b := range sorted {
+		if len(seen) >= limit {
+			return
+		}
+
+		key := bucket(b.CreatedAt.Local())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if reasons[i] == "" {
+			reasons[i] = reason
+		} else {
+			reasons[i] += ", " + reason
+		}
+	}
+}
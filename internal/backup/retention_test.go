@@ -0,0 +1,77 @@
+package backup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+func daysOfBackups(now time.Time, days int, size int64) []*config.BackupMetadata {
+	var backups []*config.BackupMetadata
+	for i := 0; i < days; i++ {
+		backups = append(backups, &config.BackupMetadata{
+			ID:        fmt.Sprintf("day-%02d", i),
+			CreatedAt: now.AddDate(0, 0, -i),
+			AddedSize: size,
+		})
+	}
+	return backups
+}
+
+func keptIDs(decisions []*PruneDecision) map[string]string {
+	kept := make(map[string]string)
+	for _, d := range decisions {
+		if d.Keep {
+			kept[d.Backup.ID] = d.Reason
+		}
+	}
+	return kept
+}
+
+func TestPlanPruneCapsDoNotRemoveBucketKeptBackups(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	backups := daysOfBackups(now, 30, 1024*1024)
+
+	tests := []struct {
+		name   string
+		policy config.RetentionPolicy
+	}{
+		{"max age", config.RetentionPolicy{KeepLast: 2, KeepDaily: 10, MaxAgeDays: 3}},
+		{"max total size", config.RetentionPolicy{KeepLast: 2, KeepDaily: 10, MaxTotalSizeMB: 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kept := keptIDs(PlanPrune(backups, test.policy, now, false))
+			if len(kept) != 10 {
+				t.Fatalf("kept %d backups, want 10: %v", len(kept), kept)
+			}
+			for i := 0; i < 10; i++ {
+				id := fmt.Sprintf("day-%02d", i)
+				if _, ok := kept[id]; !ok {
+					t.Errorf("%s removed although kept by keep_daily", id)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRetentionKeepsPreRestoreBackups(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	backups := daysOfBackups(now, 5, 1)
+	backups[3].Tags = []string{TagPreRestore}
+
+	kept := keptIDs(PlanRetention(backups, config.RetentionPolicy{KeepLast: 1}, config.RetentionPolicy{}, now, false))
+
+	if reason, ok := kept["day-03"]; !ok || reason != "pre-restore" {
+		t.Errorf("pre-restore backup: kept=%v reason=%q", ok, reason)
+	}
+	if _, ok := kept["day-00"]; !ok {
+		t.Error("newest backup removed")
+	}
+	if len(kept) != 2 {
+		t.Errorf("kept %v, want day-00 and day-03", kept)
+	}
+}
@@ -0,0 +1,216 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"backup-tool/internal/config"
+)
+
+var ErrUnsafeArchive = errors.New("архив небезопасен для распаковки")
+
+type UnsafeEntryError struct {
+	Entry  string
+	Reason string
+}
+
+func (e *UnsafeEntryError) Error() string {
+	return fmt.Sprintf("%v: %q: %s", ErrUnsafeArchive, e.Entry, e.Reason)
+}
+
+func (e *UnsafeEntryError) Unwrap() error {
+	return ErrUnsafeArchive
+}
+
+type ExtractLimitError struct {
+	Limit string
+	Value int64
+	Max   int64
+}
+
+func (e *ExtractLimitError) Error() string {
+	return fmt.Sprintf("%v: %s %d превышает лимит %d", ErrUnsafeArchive, e.Limit, e.Value, e.Max)
+}
+
+func (e *ExtractLimitError) Unwrap() error {
+	return ErrUnsafeArchive
+}
+
+var ErrNotPortable = errors.New("имена в бэкапе несовместимы с этой системой")
+
+type PortabilityError struct {
+	Problems []string
+}
+
+func (e *PortabilityError) Error() string {
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("%v: %s", ErrNotPortable, e.Problems[0])
+	}
+	return fmt.Sprintf("%v: %s (и ещё %d)", ErrNotPortable, e.Problems[0], len(e.Problems)-1)
+}
+
+func (e *PortabilityError) Unwrap() error {
+	return ErrNotPortable
+}
+
+var caseInsensitiveFS = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+var windowsDeviceNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func sanitizeEntryName(name string) (string, error) {
+	unsafe := func(reason string) (string, error) {
+		return "", &UnsafeEntryError{Entry: name, Reason: reason}
+	}
+
+	if name == "" {
+		return unsafe("пустое имя")
+	}
+	if strings.ContainsRune(name, 0) {
+		return unsafe("имя содержит нулевой байт")
+	}
+	if isAbsoluteName(name) {
+		return unsafe("абсолютный путь")
+	}
+
+	var parts []string
+	for _, part := range strings.FieldsFunc(name, isNameSeparator) {
+		switch part {
+		case ".":
+			continue
+		case "..":
+			return unsafe("выход за пределы директории через ..")
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return unsafe("пустое имя")
+	}
+
+	return strings.Join(parts, "/"), nil
+}
+
+func isNameSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+func isAbsoluteName(name string) bool {
+	if isNameSeparator(rune(name[0])) {
+		return true
+	}
+	return len(name) >= 2 && name[1] == ':'
+}
+
+func nameProblem(name string) string {
+	if runtime.GOOS != "windows" {
+		return ""
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		switch {
+		case isWindowsDeviceName(part):
+			return "зарезервированное имя устройства " + part
+		case strings.ContainsAny(part, `<>:"|?*`):
+			return "недопустимые символы в имени " + part
+		case strings.ContainsFunc(part, func(r rune) bool { return r < 32 }):
+			return "управляющие символы в имени " + part
+		case strings.HasSuffix(part, ".") || strings.HasSuffix(part, " "):
+			return "имя оканчивается точкой или пробелом: " + part
+		}
+	}
+	return ""
+}
+
+func isWindowsDeviceName(part string) bool {
+	base, _, _ := strings.Cut(part, ".")
+	return windowsDeviceNames[strings.ToUpper(strings.TrimSpace(base))]
+}
+
+func checkLinkTarget(entryName string, target string) error {
+	if target == "" {
+		return &UnsafeEntryError{Entry: entryName, Reason: "пустая символическая ссылка"}
+	}
+	if isAbsoluteName(target) {
+		return &UnsafeEntryError{Entry: entryName, Reason: "символическая ссылка на абсолютный путь " + target}
+	}
+
+	resolved := path.Join(path.Dir(entryName), strings.ReplaceAll(target, "\\", "/"))
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return &UnsafeEntryError{Entry: entryName, Reason: "символическая ссылка ведёт за пределы директории: " + target}
+	}
+	return nil
+}
+
+const maxLinkDepth = 40
+
+func resolveArchivePath(links map[string]string, name string, depth int) bool {
+	if depth > maxLinkDepth {
+		return false
+	}
+
+	parts := strings.Split(name, "/")
+	var resolved []string
+	for i, part := range parts {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return false
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, part)
+		current := strings.Join(resolved, "/")
+		if target, ok := links[current]; ok {
+			next := path.Dir(current) + "/" + target + "/" + strings.Join(parts[i+1:], "/")
+			return resolveArchivePath(links, next, depth+1)
+		}
+	}
+	return true
+}
+
+type extractBudget struct {
+	limits  config.RestoreLimits
+	entries int
+	bytes   int64
+}
+
+func (b *extractBudget) addEntry() error {
+	b.entries++
+	if b.limits.MaxEntries > 0 && b.entries > b.limits.MaxEntries {
+		return &ExtractLimitError{Limit: "количество записей", Value: int64(b.entries), Max: int64(b.limits.MaxEntries)}
+	}
+	return nil
+}
+
+func (b *extractBudget) addBytes(size int64) error {
+	b.bytes += size
+	if max := b.maxBytes(); max > 0 && b.bytes > max {
+		return &ExtractLimitError{Limit: "объём распаковки (байт)", Value: b.bytes, Max: max}
+	}
+	return nil
+}
+
+func (b *extractBudget) remaining() int64 {
+	return b.maxBytes() - b.bytes
+}
+
+func (b *extractBudget) maxBytes() int64 {
+	return b.limits.MaxSizeMB * 1024 * 1024
+}
+
+func withinRoot(root string, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -0,0 +1,151 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+const (
+	TagScheduled        = "scheduled"
+	scheduleHistoryName = "schedule-history.json"
+	maxScheduleHistory  = 100
+)
+
+const (
+	RunSucceeded = "success"
+	RunUnchanged = "unchanged"
+	RunFailed    = "failed"
+)
+
+type ScheduleRun struct {
+	ScheduleID string    `json:"schedule_id"`
+	Spec       string    `json:"spec"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Status     string    `json:"status"`
+	BackupID   string    `json:"backup_id,omitempty"`
+	AddedSize  int64     `json:"added_size,omitempty"`
+	Pruned     int       `json:"pruned,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type scheduleHistory struct {
+	Runs []*ScheduleRun `json:"runs"`
+}
+
+func RunScheduledBackup(ctx context.Context, projectPath string, schedule config.Schedule) (*ScheduleRun, error) {
+	run := &ScheduleRun{
+		ScheduleID: schedule.ID,
+		Spec:       schedule.Spec,
+		StartedAt:  time.Now(),
+	}
+
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+		run.FinishedAt = time.Now()
+		return run, nil
+	}
+
+	metadata, err := CreateBackup(ctx, projectPath, CreateOptions{
+		Tags:      []string{TagScheduled},
+		IfChanged: projectConfig.SkipUnchanged,
+	}, nil)
+
+	var unchanged *UnchangedError
+	switch {
+	case errors.As(err, &unchanged):
+		run.Status = RunUnchanged
+		run.BackupID = unchanged.Backup.ID
+	case err != nil:
+		run.Status = RunFailed
+		run.Error = err.Error()
+	default:
+		run.Status = RunSucceeded
+		run.BackupID = metadata.ID
+		run.AddedSize = metadata.AddedSize
+
+		removed, _, pruneErr := PruneBackups(projectPath)
+		run.Pruned = removed
+		if pruneErr != nil {
+			run.Error = fmt.Sprintf("не удалось применить правила хранения: %v", pruneErr)
+		}
+	}
+	run.FinishedAt = time.Now()
+
+	if err := appendScheduleRun(projectConfig.BackupPath, run); err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+func LoadScheduleHistory(projectPath string) ([]*ScheduleRun, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	return loadScheduleHistory(projectConfig.BackupPath)
+}
+
+func LastScheduleRun(history []*ScheduleRun, scheduleID string) *ScheduleRun {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ScheduleID == scheduleID {
+			return history[i]
+		}
+	}
+	return nil
+}
+
+func loadScheduleHistory(backupPath string) ([]*ScheduleRun, error) {
+	data, err := os.ReadFile(filepath.Join(backupPath, scheduleHistoryName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать историю расписания: %v", err)
+	}
+
+	var history scheduleHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать историю расписания: %v", err)
+	}
+
+	return history.Runs, nil
+}
+
+func appendScheduleRun(backupPath string, run *ScheduleRun) error {
+	lock, err := lockStorage(context.Background(), backupPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	runs, err := loadScheduleHistory(backupPath)
+	if err != nil {
+		runs = nil
+	}
+
+	runs = append(runs, run)
+	if len(runs) > maxScheduleHistory {
+		runs = runs[len(runs)-maxScheduleHistory:]
+	}
+
+	data, err := json.MarshalIndent(scheduleHistory{Runs: runs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать историю расписания: %v", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(backupPath, scheduleHistoryName), data); err != nil {
+		return fmt.Errorf("не удалось сохранить историю расписания: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+func FindBackup(backups []*config.BackupMetadata, selector string) (*config.BackupMetadata, error) {
+	for _, b := range backups {
+		if b.ID == selector {
+			return b, nil
+		}
+	}
+
+	var byName []*config.BackupMetadata
+	for _, b := range backups {
+		if b.Name == selector {
+			byName = append(byName, b)
+		}
+	}
+	if len(byName) == 1 {
+		return byName[0], nil
+	}
+	if len(byName) > 1 {
+		return nil, fmt.Errorf("найдено несколько бэкапов с именем '%s', укажите ID", selector)
+	}
+
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index < 1 || index > len(backups) {
+			return nil, fmt.Errorf("номер бэкапа %d вне диапазона 1-%d", index, len(backups))
+		}
+		return backups[index-1], nil
+	}
+
+	if len(selector) >= 4 {
+		var byPrefix []*config.BackupMetadata
+		for _, b := range backups {
+			if strings.HasPrefix(b.ID, selector) {
+				byPrefix = append(byPrefix, b)
+			}
+		}
+		if len(byPrefix) == 1 {
+			return byPrefix[0], nil
+		}
+		if len(byPrefix) > 1 {
+			return nil, fmt.Errorf("префикс ID '%s' соответствует нескольким бэкапам", selector)
+		}
+	}
+
+	return nil, fmt.Errorf("бэкап '%s' не найден", selector)
+}
+
+func UpdateBackup(projectPath string, id string, update func(*config.BackupMetadata)) (*config.BackupMetadata, error) {
+	return updateBackup(projectPath, id, func(catalog *Catalog, metadata *config.BackupMetadata) error {
+		update(metadata)
+		return nil
+	})
+}
+
+func updateBackup(projectPath string, id string, update func(*Catalog, *config.BackupMetadata) error) (*config.BackupMetadata, error) {
+	projectConfig, err := loadProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := lockStorage(context.Background(), projectConfig.BackupPath)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	catalog, err := openCatalog(projectConfig.BackupPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить каталог бэкапов: %v", err)
+	}
+
+	metadata := catalog.Find(id)
+	if metadata == nil {
+		return nil, fmt.Errorf("бэкап %s не найден", id)
+	}
+
+	if err := update(catalog, metadata); err != nil {
+		return nil, err
+	}
+
+	if err := catalog.Save(); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func RenameBackup(projectPath string, id string, name string) (*config.BackupMetadata, error) {
+	return updateBackup(projectPath, id, func(catalog *Catalog, metadata *config.BackupMetadata) error {
+		for _, b := range catalog.Backups {
+			if name != "" && b.Name == name && b.ID != id {
+				return fmt.Errorf("бэкап с именем '%s' уже существует", name)
+			}
+		}
+		metadata.Name = name
+		return nil
+	})
+}
+
+func SelectOlderThan(backups []*config.BackupMetadata, age time.Duration, now time.Time) []*config.BackupMetadata {
+	cutoff := now.Add(-age)
+
+	var selected []*config.BackupMetadata
+	for _, b := range backups {
+		if b.CreatedAt.Before(cutoff) {
+			selected = append(selected, b)
+		}
+	}
+	return selected
+}
+
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("пустой интервал")
+	}
+
+	multipliers := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+
+	if multiplier, ok := multipliers[value[len(value)-1]]; ok {
+		count, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("некорректный интервал: %s", value)
+		}
+		return time.Duration(count) * multiplier, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("некорректный интервал: %s (например 30d, 2w, 12h)", value)
+	}
+	return duration, nil
+}
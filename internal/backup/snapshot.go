@@ -0,0 +1,631 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/encryption"
+)
+
+const (
+	objectsDir   = "objects"
+	snapshotsDir = "snapshots"
+)
+
+type snapshotManifest struct {
+	archiveHeader
+	Files []snapshotFile `json:"files"`
+	Dirs  []snapshotDir  `json:"dirs,omitempty"`
+}
+
+type snapshotFile struct {
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Hash    string      `json:"hash"`
+	Object  string      `json:"object,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	ModTime time.Time   `json:"mtime,omitzero"`
+	Link    string      `json:"link,omitempty"`
+}
+
+type snapshotDir struct {
+	Path    string      `json:"path"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime,omitzero"`
+}
+
+func (f snapshotFile) entry() archiveEntry {
+	mode := f.Mode
+	if f.Link != "" {
+		mode |= os.ModeSymlink
+	}
+	return archiveEntry{Name: f.Path, Size: f.Size, Mode: mode, ModTime: f.ModTime}
+}
+
+func (f snapshotFile) objectName() string {
+	if f.Object != "" {
+		return f.Object
+	}
+	return f.Hash
+}
+
+type storedObject struct {
+	Hash       string
+	Name       string
+	Size       int64
+	StoredSize int64
+	Added      bool
+}
+
+type objectStore struct {
+	dir      string
+	level    int
+	key      *cipherKey
+	progress *progressTracker
+	mu       sync.Mutex
+}
+
+func newObjectStore(backupPath string) *objectStore {
+	return &objectStore{dir: filepath.Join(backupPath, objectsDir), level: gzip.DefaultCompression}
+}
+
+func (s *objectStore) objectPath(name string) string {
+	return filepath.Join(s.dir, name[:2], name)
+}
+
+func (s *objectStore) objectName(hash string) string {
+	if s.key == nil {
+		return hash
+	}
+
+	nameKey := hmac.New(sha256.New, s.key.key)
+	nameKey.Write([]byte("snapshot object names"))
+	mac := hmac.New(sha256.New, nameKey.Sum(nil))
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *objectStore) put(filePath string, name string) (*storedObject, error) {
+	hash, size, err := hashFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if object := s.existing(hash, size); object != nil {
+		s.progress.advance(name, size, size, size)
+		return object, nil
+	}
+
+	return s.write(filePath, name)
+}
+
+func (s *objectStore) cached(cache *fileCache, file projectEntry, name string) *storedObject {
+	hash, ok := cache.lookup(name, file.info)
+	if !ok {
+		return nil
+	}
+
+	object := s.existing(hash, file.info.Size())
+	if object != nil {
+		s.progress.advance(name, object.Size, object.Size, object.Size)
+	}
+	return object
+}
+
+func (s *objectStore) existing(hash string, size int64) *storedObject {
+	name := s.objectName(hash)
+	objectPath := s.objectPath(name)
+
+	info, err := os.Stat(objectPath)
+	if err != nil || !s.ownsObject(objectPath) {
+		return nil
+	}
+
+	return &storedObject{Hash: hash, Name: name, Size: size, StoredSize: info.Size()}
+}
+
+func (s *objectStore) ownsObject(objectPath string) bool {
+	file, err := os.Open(objectPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	salt, encrypted := encryption.ReadHeader(file)
+	if s.key == nil {
+		return !encrypted
+	}
+	return encrypted && bytes.Equal(salt, s.key.salt)
+}
+
+func (s *objectStore) write(filePath string, name string) (*storedObject, error) {
+	source, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	sourceInfo, err := source.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".object.*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	var output io.Writer = tmp
+	var encryptor *encryption.Writer
+	if s.key != nil {
+		encryptor, err = encryption.NewWriter(tmp, s.key.key, s.key.salt)
+		if err != nil {
+			tmp.Close()
+			return nil, err
+		}
+		output = encryptor
+	}
+
+	hasher := sha256.New()
+	gzipWriter, err := gzip.NewWriterLevel(output, s.level)
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+
+	size, err := io.Copy(io.MultiWriter(gzipWriter, hasher), s.progress.reader(name, sourceInfo.Size(), source))
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+	if err == nil && encryptor != nil {
+		err = encryptor.Close()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	objectName := s.objectName(hash)
+	objectPath := s.objectPath(objectName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if object := s.existing(hash, size); object != nil {
+		return object, nil
+	}
+	if _, err := os.Lstat(objectPath); !os.IsNotExist(err) {
+		return nil, fmt.Errorf("объект %s уже существует и записан другим ключом", shortHash(objectName))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmpPath, objectPath); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(objectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storedObject{Hash: hash, Name: objectName, Size: size, StoredSize: info.Size(), Added: true}, nil
+}
+
+func (s *objectStore) open(name string) (io.ReadCloser, error) {
+	if len(name) < 2 {
+		return nil, fmt.Errorf("некорректное имя объекта: %q", name)
+	}
+
+	file, _, err := openStoredFile(s.objectPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	gzipReader, err := gzip.NewReader(io.NewSectionReader(file, 0, file.Size()))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &objectReader{Reader: gzipReader, file: file}, nil
+}
+
+type objectReader struct {
+	*gzip.Reader
+	file *storedFile
+}
+
+func (r *objectReader) Close() error {
+	r.Reader.Close()
+	return r.file.Close()
+}
+
+func createSnapshot(ctx context.Context, scan *projectScan, projectConfig *config.ProjectConfig, options CreateOptions, header archiveHeader, key *cipherKey, cache *fileCache, progressCallback func(ArchiveProgress)) (*config.BackupMetadata, error) {
+	store := newObjectStore(projectConfig.BackupPath)
+	store.key = key
+	store.progress = newProgressTracker(progressCallback, scan.files, scan.bytes)
+	manifest := &snapshotManifest{archiveHeader: header}
+
+	var err error
+	store.level, err = compressionLevel(projectConfig.EffectiveCompression())
+	if err != nil {
+		return nil, err
+	}
+
+	var storedSize, addedSize int64
+	referenced := make(map[string]bool)
+	fingerprint := newTreeFingerprint()
+	added := make(map[string]bool)
+
+	prepare := func(ctx context.Context, file projectEntry) (*snapshotItem, error) {
+		return prepareSnapshotItem(store, cache, file)
+	}
+
+	consume := func(file projectEntry, item *snapshotItem) error {
+		if item.dir != nil {
+			manifest.Dirs = append(manifest.Dirs, *item.dir)
+			fingerprint.add(item.dir.Path, file.info.Mode(), 0, "")
+			return nil
+		}
+
+		manifest.Files = append(manifest.Files, item.file)
+		fingerprint.add(item.file.Path, file.info.Mode(), item.file.Size, item.file.Hash)
+		if object := item.object; object != nil {
+			cache.record(item.file.Path, file.info, object.Hash)
+			manifest.UncompressedSize += object.Size
+			if !referenced[object.Name] {
+				referenced[object.Name] = true
+				storedSize += object.StoredSize
+			}
+			if object.Added && !added[object.Name] {
+				added[object.Name] = true
+				addedSize += object.StoredSize
+			}
+		}
+		store.progress.finishFile()
+		return nil
+	}
+
+	err = runPipeline(ctx, options.Parallelism, scan.entries, prepare, consume, nil)
+	store.progress.done()
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании снимка: %v", err)
+	}
+
+	manifest.FileCount = len(manifest.Files)
+	manifest.Fingerprint = fingerprint.sum()
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сериализовать снимок: %v", err)
+	}
+
+	if key != nil {
+		data, err = encryption.Encrypt(data, key.key, key.salt)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось зашифровать снимок: %v", err)
+		}
+	}
+
+	fileName := path.Join(snapshotsDir, fmt.Sprintf("snapshot_%s_%s.json", header.CreatedAt.Format("20060102_150405"), header.ID[:8]))
+	manifestPath := filepath.Join(projectConfig.BackupPath, filepath.FromSlash(fileName))
+
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию снимков: %v", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := writeFileAtomic(manifestPath, data); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить снимок: %v", err)
+	}
+
+	checksum := sha256.Sum256(data)
+
+	metadata := &config.BackupMetadata{
+		ID:               header.ID,
+		Name:             header.Name,
+		Tags:             header.Tags,
+		Storage:          config.StorageSnapshot,
+		Encrypted:        key != nil,
+		Size:             storedSize + int64(len(data)),
+		AddedSize:        addedSize + int64(len(data)),
+		UncompressedSize: manifest.UncompressedSize,
+		FileCount:        manifest.FileCount,
+		Checksum:         hex.EncodeToString(checksum[:]),
+		Fingerprint:      manifest.Fingerprint,
+		CreatedAt:        header.CreatedAt,
+		FileName:         fileName,
+		FilePath:         manifestPath,
+	}
+
+	return metadata, nil
+}
+
+type snapshotItem struct {
+	dir    *snapshotDir
+	file   snapshotFile
+	object *storedObject
+}
+
+func prepareSnapshotItem(store *objectStore, cache *fileCache, file projectEntry) (*snapshotItem, error) {
+	name := filepath.ToSlash(file.relPath)
+
+	if file.info.IsDir() {
+		return &snapshotItem{dir: &snapshotDir{
+			Path:    name,
+			Mode:    file.info.Mode().Perm(),
+			ModTime: file.info.ModTime(),
+		}}, nil
+	}
+
+	if isSymlink(file.info.Mode()) {
+		store.progress.startFile(name, 0)
+		linkTarget, err := readLink(file.path)
+		if err != nil {
+			return nil, err
+		}
+
+		linkHash := sha256.Sum256([]byte(linkTarget))
+		return &snapshotItem{file: snapshotFile{
+			Path: name,
+			Size: int64(len(linkTarget)),
+			Hash: hex.EncodeToString(linkHash[:]),
+			Mode: file.info.Mode().Perm(),
+			Link: linkTarget,
+		}}, nil
+	}
+
+	object := store.cached(cache, file, name)
+	if object == nil {
+		var err error
+		object, err = store.put(file.path, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	item := &snapshotItem{
+		file: snapshotFile{
+			Path:    name,
+			Size:    object.Size,
+			Hash:    object.Hash,
+			Mode:    file.info.Mode().Perm(),
+			ModTime: file.info.ModTime(),
+		},
+		object: object,
+	}
+	if object.Name != object.Hash {
+		item.file.Object = object.Name
+	}
+	return item, nil
+}
+
+func restoreSnapshot(ctx context.Context, manifestPath string, x *extractor, progressCallback func(ArchiveProgress)) error {
+	manifest, err := readSnapshotManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать снимок: %v", err)
+	}
+
+	store := newObjectStore(filepath.Dir(filepath.Dir(manifestPath)))
+
+	for _, dir := range manifest.Dirs {
+		if err := x.check(archiveEntry{Name: dir.Path, IsDir: true}, ""); err != nil {
+			return err
+		}
+	}
+	for _, file := range manifest.Files {
+		if err := x.check(file.entry(), file.Link); err != nil {
+			return err
+		}
+	}
+	if err := x.finishCheck(); err != nil {
+		return err
+	}
+
+	for _, dir := range manifest.Dirs {
+		entry := archiveEntry{Name: dir.Path, Mode: os.ModeDir | dir.Mode, ModTime: dir.ModTime, IsDir: true}
+		if err := x.extract(entry, nil); err != nil {
+			return fmt.Errorf("не удалось восстановить %s: %v", dir.Path, err)
+		}
+	}
+
+	progress := newProgressTracker(progressCallback, len(manifest.Files), x.checked.bytes)
+
+	for _, file := range manifest.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := restoreObject(ctx, store, file, x, progress); err != nil {
+			return fmt.Errorf("не удалось восстановить %s: %v", file.Path, err)
+		}
+	}
+
+	progress.done()
+	return nil
+}
+
+func restoreObject(ctx context.Context, store *objectStore, file snapshotFile, x *extractor, progress *progressTracker) error {
+	if file.Link != "" {
+		return extractWithProgress(x, progress, file.entry(), strings.NewReader(file.Link))
+	}
+
+	reader, err := store.open(file.objectName())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	if err := extractWithProgress(x, progress, file.entry(), io.TeeReader(&contextReader{ctx: ctx, reader: reader}, hasher)); err != nil {
+		return err
+	}
+
+	if hex.EncodeToString(hasher.Sum(nil)) != file.Hash {
+		return fmt.Errorf("контрольная сумма объекта не совпадает")
+	}
+
+	return nil
+}
+
+func readSnapshotManifest(manifestPath string) (*snapshotManifest, error) {
+	data, err := readStoredFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest snapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+func inspectSnapshots(backupPath string) ([]*config.BackupMetadata, []error, error) {
+	entries, err := os.ReadDir(filepath.Join(backupPath, snapshotsDir))
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type loadedSnapshot struct {
+		metadata *config.BackupMetadata
+		manifest *snapshotManifest
+	}
+
+	var snapshots []loadedSnapshot
+	var skipped []error
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" || !strings.HasPrefix(entry.Name(), "snapshot_") {
+			continue
+		}
+
+		fileName := path.Join(snapshotsDir, entry.Name())
+		manifestPath := filepath.Join(backupPath, snapshotsDir, entry.Name())
+
+		manifest, err := readSnapshotManifest(manifestPath)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("не удалось прочитать снимок %s: %v", entry.Name(), err))
+			continue
+		}
+
+		checksum, err := fileChecksum(manifestPath)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("не удалось прочитать снимок %s: %v", entry.Name(), err))
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("не удалось прочитать снимок %s: %v", entry.Name(), err))
+			continue
+		}
+
+		snapshots = append(snapshots, loadedSnapshot{
+			metadata: &config.BackupMetadata{
+				ID:               manifest.ID,
+				Name:             manifest.Name,
+				Tags:             manifest.Tags,
+				Storage:          config.StorageSnapshot,
+				Encrypted:        isStoredFileEncrypted(manifestPath),
+				Size:             info.Size(),
+				AddedSize:        info.Size(),
+				UncompressedSize: manifest.UncompressedSize,
+				FileCount:        len(manifest.Files),
+				Checksum:         checksum,
+				Fingerprint:      manifest.Fingerprint,
+				CreatedAt:        manifest.CreatedAt,
+				FileName:         fileName,
+				FilePath:         manifestPath,
+			},
+			manifest: manifest,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].manifest.CreatedAt.Before(snapshots[j].manifest.CreatedAt)
+	})
+
+	store := newObjectStore(backupPath)
+	seen := make(map[string]bool)
+	var backups []*config.BackupMetadata
+
+	for _, snapshot := range snapshots {
+		counted := make(map[string]bool)
+		for _, file := range snapshot.manifest.Files {
+			name := file.objectName()
+			if file.Link != "" || counted[name] {
+				continue
+			}
+			counted[name] = true
+
+			info, err := os.Stat(store.objectPath(name))
+			if err != nil {
+				continue
+			}
+
+			snapshot.metadata.Size += info.Size()
+			if !seen[name] {
+				seen[name] = true
+				snapshot.metadata.AddedSize += info.Size()
+			}
+		}
+		backups = append(backups, snapshot.metadata)
+	}
+
+	return backups, skipped, nil
+}
+
+func hashFile(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
@@ -0,0 +1,103 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"backup-tool/internal/config"
+	"backup-tool/internal/encryption"
+)
+
+func snapshotObjects(t *testing.T, metadata *config.BackupMetadata) map[string]string {
+	t.Helper()
+
+	manifest, err := readSnapshotManifest(metadata.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects := make(map[string]string)
+	for _, file := range manifest.Files {
+		if file.Link == "" {
+			objects[file.Path] = file.objectName()
+		}
+	}
+	return objects
+}
+
+func objectSalt(t *testing.T, store *objectStore, name string) []byte {
+	t.Helper()
+
+	file, err := os.Open(store.objectPath(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	salt, _ := encryption.ReadHeader(file)
+	return salt
+}
+
+func TestSnapshotObjectsAreScopedToKey(t *testing.T) {
+	projectPath := newTestProject(t, 10)
+	SetPassphraseProvider(func(string, *config.EncryptionConfig) (string, error) { return "secret", nil })
+	t.Cleanup(func() { SetPassphraseProvider(nil) })
+
+	create := func() *config.BackupMetadata {
+		t.Helper()
+		metadata, err := CreateBackup(context.Background(), projectPath, CreateOptions{Storage: config.StorageSnapshot, NoCache: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := VerifyBackup(metadata, nil); !result.OK() {
+			t.Fatalf("verify %s: %v", metadata.ID, result.Problems)
+		}
+		return metadata
+	}
+
+	plain := snapshotObjects(t, create())
+
+	var keyed []map[string]string
+	var salts [][]byte
+	for range 2 {
+		if err := EnableEncryption(projectPath, "secret", "", ""); err != nil {
+			t.Fatal(err)
+		}
+		projectConfig, err := loadProjectConfig(projectPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := projectKey(projectConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyed = append(keyed, snapshotObjects(t, create()))
+		salts = append(salts, key.salt)
+	}
+
+	projectConfig, err := config.LoadProjectConfig(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newObjectStore(projectConfig.BackupPath)
+
+	for path, plainName := range plain {
+		for i, objects := range keyed {
+			name := objects[path]
+			if name == plainName {
+				t.Errorf("%s: encrypted object named by its content hash", path)
+			}
+			if i > 0 && name == keyed[i-1][path] {
+				t.Errorf("%s: object shared between keys", path)
+			}
+			if salt := objectSalt(t, store, name); !bytes.Equal(salt, salts[i]) {
+				t.Errorf("%s: object %s written with another key", path, name)
+			}
+		}
+		if salt := objectSalt(t, store, plainName); salt != nil {
+			t.Errorf("%s: unencrypted object %s was overwritten", path, plainName)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+const (
+	manifestEntryName      = ".backup-manifest.json"
+	archiveManifestVersion = 1
+)
+
+type archiveManifest struct {
+	Version int            `json:"version"`
+	Files   []manifestFile `json:"files"`
+}
+
+type manifestFile struct {
+	Path   string      `json:"path"`
+	Size   int64       `json:"size"`
+	Mode   os.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256"`
+}
+
+type VerifyResult struct {
+	Backup      *config.BackupMetadata
+	Files       int
+	HasManifest bool
+	KeyRequired bool
+	Problems    []string
+}
+
+func (r *VerifyResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *VerifyResult) addProblem(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+func (r *VerifyResult) Err() error {
+	if r.OK() {
+		return nil
+	}
+	if r.KeyRequired {
+		return fmt.Errorf("%s", r.Problems[0])
+	}
+	if len(r.Problems) == 1 {
+		return fmt.Errorf("бэкап повреждён: %s", r.Problems[0])
+	}
+	return fmt.Errorf("бэкап повреждён: %s (и ещё %d проблем)", r.Problems[0], len(r.Problems)-1)
+}
+
+func writeArchiveManifest(archive archiveWriter, manifest *archiveManifest, modTime time.Time) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	writer, err := archive.Create(archiveEntry{Name: manifestEntryName, Size: int64(len(data)), Mode: 0644, ModTime: modTime})
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	return err
+}
+
+func readArchiveManifest(reader io.Reader) (*archiveManifest, error) {
+	var manifest archiveManifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func VerifyBackup(metadata *config.BackupMetadata, progressCallback func(ArchiveProgress)) *VerifyResult {
+	result := &VerifyResult{Backup: metadata}
+
+	if metadata.Checksum != "" {
+		checksum, err := fileChecksum(metadata.FilePath)
+		if err != nil {
+			result.addProblem("не удалось прочитать %s: %v", metadata.FileName, err)
+			return result
+		}
+		if checksum != metadata.Checksum {
+			result.addProblem("контрольная сумма %s не совпадает с каталогом", metadata.FileName)
+		}
+	}
+
+	if metadata.Storage == config.StorageSnapshot {
+		verifySnapshot(metadata, result, progressCallback)
+	} else {
+		verifyArchive(metadata, result, progressCallback)
+	}
+
+	if metadata.FileCount > 0 && result.Files != metadata.FileCount && result.OK() {
+		result.addProblem("в бэкапе %d файлов вместо %d", result.Files, metadata.FileCount)
+	}
+
+	return result
+}
+
+func verifyArchive(metadata *config.BackupMetadata, result *VerifyResult, progressCallback func(ArchiveProgress)) {
+	reader, err := openArchive(metadata.FilePath, archiveFormat(metadata))
+	if err != nil {
+		result.KeyRequired = errors.Is(err, ErrKeyRequired)
+		result.addProblem("не удалось открыть архив: %v", err)
+		return
+	}
+	defer reader.Close()
+
+	var manifest *archiveManifest
+	actual := make(map[string]manifestFile)
+	var order []string
+
+	err = reader.Walk(func(entry archiveEntry, content io.Reader) error {
+		if entry.Name == manifestEntryName {
+			parsed, err := readArchiveManifest(content)
+			if err != nil {
+				result.addProblem("манифест архива повреждён: %v", err)
+				return nil
+			}
+			manifest = parsed
+			return nil
+		}
+		if entry.IsDir || isReservedEntry(entry.Name) {
+			return nil
+		}
+
+		entryPath := entry.Name
+		if progressCallback != nil {
+			progressCallback(ArchiveProgress{Current: result.Files, Total: metadata.FileCount, File: entryPath})
+		}
+
+		result.Files++
+
+		hash, size, err := hashReader(content)
+		if err != nil {
+			result.addProblem("%s: %v", entryPath, err)
+			return nil
+		}
+
+		actual[entryPath] = manifestFile{Path: entryPath, Size: size, Mode: manifestMode(entry.Mode), SHA256: hash}
+		order = append(order, entryPath)
+		return nil
+	})
+	if err != nil {
+		result.addProblem("архив повреждён: %v", err)
+		return
+	}
+
+	if manifest == nil {
+		return
+	}
+	result.HasManifest = true
+
+	expected := make(map[string]manifestFile, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		expected[entry.Path] = entry
+	}
+
+	for _, entryPath := range order {
+		file := actual[entryPath]
+		entry, ok := expected[entryPath]
+		switch {
+		case !ok:
+			result.addProblem("%s: файл отсутствует в манифесте", entryPath)
+		case entry.Size != file.Size:
+			result.addProblem("%s: размер %d вместо %d", entryPath, file.Size, entry.Size)
+		case entry.SHA256 != file.SHA256:
+			result.addProblem("%s: SHA-256 не совпадает", entryPath)
+		case entry.Mode != 0 && manifestMode(entry.Mode) != file.Mode:
+			result.addProblem("%s: права %v вместо %v", entryPath, file.Mode, manifestMode(entry.Mode))
+		}
+	}
+
+	var missing []string
+	for entryPath := range expected {
+		if _, ok := actual[entryPath]; !ok {
+			missing = append(missing, entryPath)
+		}
+	}
+	sort.Strings(missing)
+	for _, entryPath := range missing {
+		result.addProblem("%s: файл из манифеста отсутствует в архиве", entryPath)
+	}
+}
+
+func manifestMode(mode os.FileMode) os.FileMode {
+	return mode.Perm() | mode&os.ModeSymlink
+}
+
+func hashReader(reader io.Reader) (string, int64, error) {
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, reader)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+func verifySnapshot(metadata *config.BackupMetadata, result *VerifyResult, progressCallback func(ArchiveProgress)) {
+	manifest, err := readSnapshotManifest(metadata.FilePath)
+	if err != nil {
+		result.KeyRequired = errors.Is(err, ErrKeyRequired)
+		result.addProblem("не удалось прочитать снимок: %v", err)
+		return
+	}
+	result.HasManifest = true
+
+	store := newObjectStore(filepath.Dir(filepath.Dir(metadata.FilePath)))
+
+	for i, file := range manifest.Files {
+		if progressCallback != nil {
+			progressCallback(ArchiveProgress{Current: i, Total: len(manifest.Files), File: file.Path})
+		}
+
+		result.Files++
+
+		if file.Link != "" {
+			if hash, _, _ := hashReader(strings.NewReader(file.Link)); hash != file.Hash {
+				result.addProblem("%s: цель ссылки не совпадает с хешем", file.Path)
+			}
+			continue
+		}
+
+		reader, err := store.open(file.objectName())
+		if err != nil {
+			result.addProblem("%s: объект %s недоступен: %v", file.Path, shortHash(file.Hash), err)
+			continue
+		}
+
+		hash, size, err := hashReader(reader)
+		reader.Close()
+		if err != nil {
+			result.addProblem("%s: объект %s повреждён: %v", file.Path, shortHash(file.Hash), err)
+			continue
+		}
+
+		if size != file.Size || hash != file.Hash {
+			result.addProblem("%s: содержимое объекта %s не совпадает с хешем", file.Path, shortHash(file.Hash))
+		}
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -11,23 +11,99 @@ import (
 )
 
 type ProjectConfig struct {
-	ID         string    `json:"id"`
-	Name       string    `json:"name"`
-	CreatedAt  time.Time `json:"created_at"`
-	BackupPath string    `json:"backup_path"`
-	Excludes   []string  `json:"excludes"`
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	CreatedAt      time.Time         `json:"created_at"`
+	BackupPath     string            `json:"backup_path"`
+	Excludes       []string          `json:"excludes,omitempty"`
+	ExcludesAppend []string          `json:"excludes_append,omitempty"`
+	UseGitignore   bool              `json:"use_gitignore,omitempty"`
+	SkipUnchanged  bool              `json:"skip_unchanged,omitempty"`
+	Storage        string            `json:"storage,omitempty"`
+	Compression    string            `json:"compression,omitempty"`
+	Format         string            `json:"format,omitempty"`
+	Symlinks       string            `json:"symlinks,omitempty"`
+	Parallelism    int               `json:"parallelism,omitempty"`
+	Retention      RetentionPolicy   `json:"retention"`
+	AutoRetention  RetentionPolicy   `json:"auto_retention,omitzero"`
+	Restore        RestoreLimits     `json:"restore,omitzero"`
+	Watch          WatchSettings     `json:"watch,omitzero"`
+	Schedules      []Schedule        `json:"schedules,omitempty"`
+	Encryption     *EncryptionConfig `json:"encryption,omitempty"`
+
+	global             *GlobalConfig
+	missingBackupPath  string
+	recordedBackupPath string
+	resolvedBackupPath string
+}
+
+type EncryptionConfig struct {
+	Enabled       bool   `json:"enabled"`
+	Salt          string `json:"salt"`
+	KeyCheck      string `json:"key_check"`
+	KeyFile       string `json:"key_file,omitempty"`
+	PassphraseEnv string `json:"passphrase_env,omitempty"`
+}
+
+type RetentionPolicy struct {
+	KeepLast       int   `json:"keep_last,omitempty"`
+	KeepDaily      int   `json:"keep_daily,omitempty"`
+	KeepWeekly     int   `json:"keep_weekly,omitempty"`
+	KeepMonthly    int   `json:"keep_monthly,omitempty"`
+	MaxTotalSizeMB int64 `json:"max_total_size_mb,omitempty"`
+	MaxAgeDays     int   `json:"max_age_days,omitempty"`
+}
+
+func (p RetentionPolicy) IsEmpty() bool {
+	return p == RetentionPolicy{}
+}
+
+type RestoreLimits struct {
+	MaxSizeMB  int64 `json:"max_size_mb,omitempty"`
+	MaxEntries int   `json:"max_entries,omitempty"`
+}
+
+type WatchSettings struct {
+	QuietSeconds    int `json:"quiet_seconds,omitempty"`
+	IntervalMinutes int `json:"interval_minutes,omitempty"`
+}
+
+type Schedule struct {
+	ID   string `json:"id"`
+	Spec string `json:"spec"`
 }
 
 type BackupMetadata struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name,omitempty"`
-	Size      int64     `json:"size"`
-	CreatedAt time.Time `json:"created_at"`
-	FilePath  string    `json:"file_path"`
+	ID               string    `json:"id"`
+	Name             string    `json:"name,omitempty"`
+	Storage          string    `json:"storage,omitempty"`
+	Format           string    `json:"format,omitempty"`
+	Size             int64     `json:"size"`
+	AddedSize        int64     `json:"added_size"`
+	UncompressedSize int64     `json:"uncompressed_size"`
+	FileCount        int       `json:"file_count"`
+	Checksum         string    `json:"checksum"`
+	Fingerprint      string    `json:"fingerprint,omitempty"`
+	Pinned           bool      `json:"pinned,omitempty"`
+	Encrypted        bool      `json:"encrypted,omitempty"`
+	Tags             []string  `json:"tags,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	FileName         string    `json:"file_name"`
+	FilePath         string    `json:"-"`
 }
 
 type GlobalConfig struct {
-	DefaultExcludes []string `json:"default_excludes"`
+	DefaultExcludes []string        `json:"default_excludes"`
+	Storage         string          `json:"storage,omitempty"`
+	Compression     string          `json:"compression,omitempty"`
+	Format          string          `json:"format,omitempty"`
+	Symlinks        string          `json:"symlinks,omitempty"`
+	Parallelism     int             `json:"parallelism,omitempty"`
+	Retention       RetentionPolicy `json:"retention"`
+	AutoRetention   RetentionPolicy `json:"auto_retention,omitzero"`
+	Restore         RestoreLimits   `json:"restore,omitzero"`
+	Watch           WatchSettings   `json:"watch,omitzero"`
+	UI              UIPreferences   `json:"ui"`
 }
 
 const (
@@ -35,27 +111,46 @@ const (
 	AppDataDir     = "ProjectBackup"
 )
 
-func GetAppDataPath() (string, error) {
-	appData := os.Getenv("APPDATA")
-	if appData == "" {
-		return "", fmt.Errorf("переменная окружения APPDATA не найдена")
-	}
+const (
+	StorageArchive  = "archive"
+	StorageSnapshot = "snapshot"
+)
 
-	backupDir := filepath.Join(appData, AppDataDir)
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return "", fmt.Errorf("не удалось создать директорию бэкапов: %v", err)
-	}
+const (
+	FormatZip      = "zip"
+	FormatZipStore = "zip-store"
+	FormatTarGz    = "tar.gz"
+	FormatTarZst   = "tar.zst"
+)
 
-	return backupDir, nil
-}
+const (
+	DefaultRestoreMaxSizeMB  = 32 * 1024
+	DefaultRestoreMaxEntries = 1000000
+)
+
+const (
+	DefaultWatchQuietSeconds    = 30
+	DefaultWatchIntervalMinutes = 10
+)
+
+const (
+	DefaultAutoKeepLast  = 20
+	DefaultAutoKeepDaily = 7
+)
+
+const (
+	SymlinksPreserve = "preserve"
+	SymlinksFollow   = "follow"
+	SymlinksSkip     = "skip"
+)
 
 func GetProjectBackupPath(projectID string) (string, error) {
-	appDataPath, err := GetAppDataPath()
+	storageRoot, err := GetStorageRoot()
 	if err != nil {
 		return "", err
 	}
 
-	projectPath := filepath.Join(appDataPath, projectID)
+	projectPath := filepath.Join(storageRoot, projectID)
 	if err := os.MkdirAll(projectPath, 0755); err != nil {
 		return "", fmt.Errorf("не удалось создать директорию проекта: %v", err)
 	}
@@ -76,13 +171,27 @@ func LoadProjectConfig(dir string) (*ProjectConfig, error) {
 		return nil, fmt.Errorf("не удалось разобрать конфигурацию: %v", err)
 	}
 
+	if err := config.resolveBackupPath(); err != nil {
+		return nil, err
+	}
+
+	config.global, err = LoadGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
 func (c *ProjectConfig) Save(dir string) error {
 	configPath := filepath.Join(dir, ConfigFileName)
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	saved := *c
+	if c.resolvedBackupPath != "" && c.BackupPath == c.resolvedBackupPath {
+		saved.BackupPath = c.recordedBackupPath
+	}
+
+	data, err := json.MarshalIndent(&saved, "", "  ")
 	if err != nil {
 		return fmt.Errorf("не удалось сериализовать конфигурацию: %v", err)
 	}
@@ -99,17 +208,6 @@ func NewProjectConfig(name string) *ProjectConfig {
 		ID:        uuid.New().String(),
 		Name:      name,
 		CreatedAt: time.Now(),
-		Excludes: []string{
-			"node_modules/",
-			".git/",
-			"*.tmp",
-			"*.log",
-			"build/",
-			"dist/",
-			".env*",
-			"*.exe",
-			"*.dll",
-		},
 	}
 }
 
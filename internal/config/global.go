@@ -0,0 +1,189 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const GlobalConfigFileName = "config.json"
+
+const (
+	CompressionDefault = "default"
+	CompressionFast    = "fast"
+	CompressionBest    = "best"
+	CompressionNone    = "none"
+)
+
+type UIPreferences struct {
+	NoColor      bool `json:"no_color,omitempty"`
+	HideProgress bool `json:"hide_progress,omitempty"`
+}
+
+func NewGlobalConfig() *GlobalConfig {
+	return &GlobalConfig{
+		DefaultExcludes: GetDefaultExcludes(),
+	}
+}
+
+func GetGlobalConfigPath() (string, error) {
+	storageRoot, err := GetStorageRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(storageRoot, GlobalConfigFileName), nil
+}
+
+func LoadGlobalConfig() (*GlobalConfig, error) {
+	configPath, err := GetGlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return NewGlobalConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать глобальную конфигурацию: %v", err)
+	}
+
+	var config GlobalConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать глобальную конфигурацию %s: %v", configPath, err)
+	}
+
+	if config.DefaultExcludes == nil {
+		config.DefaultExcludes = GetDefaultExcludes()
+	}
+
+	return &config, nil
+}
+
+func (g *GlobalConfig) Save() error {
+	configPath, err := GetGlobalConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать глобальную конфигурацию: %v", err)
+	}
+
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("не удалось сохранить глобальную конфигурацию: %v", err)
+	}
+
+	return nil
+}
+
+func (c *ProjectConfig) Global() *GlobalConfig {
+	if c.global == nil {
+		c.global = NewGlobalConfig()
+	}
+	return c.global
+}
+
+func (c *ProjectConfig) EffectiveStorage() string {
+	if c.Storage != "" {
+		return c.Storage
+	}
+	if c.Global().Storage != "" {
+		return c.Global().Storage
+	}
+	return StorageArchive
+}
+
+func (c *ProjectConfig) EffectiveCompression() string {
+	if c.Compression != "" {
+		return c.Compression
+	}
+	if c.Global().Compression != "" {
+		return c.Global().Compression
+	}
+	return CompressionDefault
+}
+
+func (c *ProjectConfig) EffectiveFormat() string {
+	if c.Format != "" {
+		return c.Format
+	}
+	if c.Global().Format != "" {
+		return c.Global().Format
+	}
+	return FormatZip
+}
+
+func (c *ProjectConfig) EffectiveSymlinks() string {
+	if c.Symlinks != "" {
+		return c.Symlinks
+	}
+	if c.Global().Symlinks != "" {
+		return c.Global().Symlinks
+	}
+	return SymlinksPreserve
+}
+
+func (c *ProjectConfig) EffectiveParallelism() int {
+	if c.Parallelism > 0 {
+		return c.Parallelism
+	}
+	if c.Global().Parallelism > 0 {
+		return c.Global().Parallelism
+	}
+	return runtime.NumCPU()
+}
+
+func (c *ProjectConfig) EffectiveRestoreLimits() RestoreLimits {
+	limits := c.Restore
+	if limits.MaxSizeMB == 0 {
+		limits.MaxSizeMB = c.Global().Restore.MaxSizeMB
+	}
+	if limits.MaxSizeMB == 0 {
+		limits.MaxSizeMB = DefaultRestoreMaxSizeMB
+	}
+	if limits.MaxEntries == 0 {
+		limits.MaxEntries = c.Global().Restore.MaxEntries
+	}
+	if limits.MaxEntries == 0 {
+		limits.MaxEntries = DefaultRestoreMaxEntries
+	}
+	return limits
+}
+
+func (c *ProjectConfig) EffectiveAutoRetention() RetentionPolicy {
+	if !c.AutoRetention.IsEmpty() {
+		return c.AutoRetention
+	}
+	if global := c.Global().AutoRetention; !global.IsEmpty() {
+		return global
+	}
+	return RetentionPolicy{KeepLast: DefaultAutoKeepLast, KeepDaily: DefaultAutoKeepDaily}
+}
+
+func (c *ProjectConfig) EffectiveWatch() WatchSettings {
+	watch := c.Watch
+	if watch.QuietSeconds == 0 {
+		watch.QuietSeconds = c.Global().Watch.QuietSeconds
+	}
+	if watch.QuietSeconds == 0 {
+		watch.QuietSeconds = DefaultWatchQuietSeconds
+	}
+	if watch.IntervalMinutes == 0 {
+		watch.IntervalMinutes = c.Global().Watch.IntervalMinutes
+	}
+	if watch.IntervalMinutes == 0 {
+		watch.IntervalMinutes = DefaultWatchIntervalMinutes
+	}
+	return watch
+}
+
+func (c *ProjectConfig) EffectiveRetention() RetentionPolicy {
+	if !c.Retention.IsEmpty() {
+		return c.Retention
+	}
+	return c.Global().Retention
+}
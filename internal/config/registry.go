@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const RegistryFileName = "projects.json"
+
+type RegisteredProject struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+type registry struct {
+	Projects []RegisteredProject `json:"projects"`
+}
+
+func getRegistryPath() (string, error) {
+	storageRoot, err := GetStorageRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(storageRoot, RegistryFileName), nil
+}
+
+func LoadRegisteredProjects() ([]RegisteredProject, error) {
+	registryPath, err := getRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(registryPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать реестр проектов: %v", err)
+	}
+
+	var loaded registry
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать реестр проектов %s: %v", registryPath, err)
+	}
+
+	return loaded.Projects, nil
+}
+
+func RegisterProject(id, dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("некорректный путь проекта %s: %v", dir, err)
+	}
+
+	projects, err := LoadRegisteredProjects()
+	if err != nil {
+		return err
+	}
+
+	for i, project := range projects {
+		if project.ID == id {
+			if project.Path == absDir {
+				return nil
+			}
+			projects = append(projects[:i], projects[i+1:]...)
+			break
+		}
+	}
+
+	projects = append(projects, RegisteredProject{ID: id, Path: absDir})
+	return saveRegisteredProjects(projects)
+}
+
+func UnregisterProject(id string) error {
+	projects, err := LoadRegisteredProjects()
+	if err != nil {
+		return err
+	}
+
+	kept := projects[:0]
+	for _, project := range projects {
+		if project.ID != id {
+			kept = append(kept, project)
+		}
+	}
+	if len(kept) == len(projects) {
+		return nil
+	}
+
+	return saveRegisteredProjects(kept)
+}
+
+func saveRegisteredProjects(projects []RegisteredProject) error {
+	registryPath, err := getRegistryPath()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Path < projects[j].Path
+	})
+
+	data, err := json.MarshalIndent(registry{Projects: projects}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать реестр проектов: %v", err)
+	}
+
+	if err := os.WriteFile(registryPath, data, 0644); err != nil {
+		return fmt.Errorf("не удалось сохранить реестр проектов: %v", err)
+	}
+
+	return nil
+}
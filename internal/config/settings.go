@@ -0,0 +1,359 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	LayerDefault = "default"
+	LayerGlobal  = "global"
+	LayerProject = "project"
+)
+
+type setting struct {
+	key       string
+	project   func(*ProjectConfig) any
+	global    func(*GlobalConfig) any
+	inherited func(*ProjectConfig) bool
+	validate  func(string) error
+}
+
+var settings = []setting{
+	{
+		key:       "excludes",
+		project:   func(c *ProjectConfig) any { return &c.Excludes },
+		global:    func(g *GlobalConfig) any { return &g.DefaultExcludes },
+		inherited: func(c *ProjectConfig) bool { return c.Excludes == nil },
+	},
+	{
+		key:     "excludes_append",
+		project: func(c *ProjectConfig) any { return &c.ExcludesAppend },
+	},
+	{
+		key:     "use_gitignore",
+		project: func(c *ProjectConfig) any { return &c.UseGitignore },
+	},
+	{
+		key:     "skip_unchanged",
+		project: func(c *ProjectConfig) any { return &c.SkipUnchanged },
+	},
+	{
+		key:       "storage",
+		project:   func(c *ProjectConfig) any { return &c.Storage },
+		global:    func(g *GlobalConfig) any { return &g.Storage },
+		inherited: func(c *ProjectConfig) bool { return c.Storage == "" },
+		validate:  oneOf(StorageArchive, StorageSnapshot),
+	},
+	{
+		key:       "compression",
+		project:   func(c *ProjectConfig) any { return &c.Compression },
+		global:    func(g *GlobalConfig) any { return &g.Compression },
+		inherited: func(c *ProjectConfig) bool { return c.Compression == "" },
+		validate:  oneOf(CompressionDefault, CompressionFast, CompressionBest, CompressionNone),
+	},
+	{
+		key:       "format",
+		project:   func(c *ProjectConfig) any { return &c.Format },
+		global:    func(g *GlobalConfig) any { return &g.Format },
+		inherited: func(c *ProjectConfig) bool { return c.Format == "" },
+		validate:  oneOf(FormatZip, FormatZipStore, FormatTarGz, FormatTarZst),
+	},
+	{
+		key:       "symlinks",
+		project:   func(c *ProjectConfig) any { return &c.Symlinks },
+		global:    func(g *GlobalConfig) any { return &g.Symlinks },
+		inherited: func(c *ProjectConfig) bool { return c.Symlinks == "" },
+		validate:  oneOf(SymlinksPreserve, SymlinksFollow, SymlinksSkip),
+	},
+	{
+		key:       "parallelism",
+		project:   func(c *ProjectConfig) any { return &c.Parallelism },
+		global:    func(g *GlobalConfig) any { return &g.Parallelism },
+		inherited: func(c *ProjectConfig) bool { return c.Parallelism == 0 },
+	},
+	retentionSetting("retention.keep_last", func(p *RetentionPolicy) any { return &p.KeepLast }),
+	retentionSetting("retention.keep_daily", func(p *RetentionPolicy) any { return &p.KeepDaily }),
+	retentionSetting("retention.keep_weekly", func(p *RetentionPolicy) any { return &p.KeepWeekly }),
+	retentionSetting("retention.keep_monthly", func(p *RetentionPolicy) any { return &p.KeepMonthly }),
+	retentionSetting("retention.max_total_size_mb", func(p *RetentionPolicy) any { return &p.MaxTotalSizeMB }),
+	retentionSetting("retention.max_age_days", func(p *RetentionPolicy) any { return &p.MaxAgeDays }),
+	autoRetentionSetting("auto_retention.keep_last", func(p *RetentionPolicy) any { return &p.KeepLast }),
+	autoRetentionSetting("auto_retention.keep_daily", func(p *RetentionPolicy) any { return &p.KeepDaily }),
+	autoRetentionSetting("auto_retention.keep_weekly", func(p *RetentionPolicy) any { return &p.KeepWeekly }),
+	autoRetentionSetting("auto_retention.keep_monthly", func(p *RetentionPolicy) any { return &p.KeepMonthly }),
+	autoRetentionSetting("auto_retention.max_total_size_mb", func(p *RetentionPolicy) any { return &p.MaxTotalSizeMB }),
+	autoRetentionSetting("auto_retention.max_age_days", func(p *RetentionPolicy) any { return &p.MaxAgeDays }),
+	{
+		key:       "restore.max_size_mb",
+		project:   func(c *ProjectConfig) any { return &c.Restore.MaxSizeMB },
+		global:    func(g *GlobalConfig) any { return &g.Restore.MaxSizeMB },
+		inherited: func(c *ProjectConfig) bool { return c.Restore.MaxSizeMB == 0 },
+	},
+	{
+		key:       "restore.max_entries",
+		project:   func(c *ProjectConfig) any { return &c.Restore.MaxEntries },
+		global:    func(g *GlobalConfig) any { return &g.Restore.MaxEntries },
+		inherited: func(c *ProjectConfig) bool { return c.Restore.MaxEntries == 0 },
+	},
+	{
+		key:       "watch.quiet_seconds",
+		project:   func(c *ProjectConfig) any { return &c.Watch.QuietSeconds },
+		global:    func(g *GlobalConfig) any { return &g.Watch.QuietSeconds },
+		inherited: func(c *ProjectConfig) bool { return c.Watch.QuietSeconds == 0 },
+	},
+	{
+		key:       "watch.interval_minutes",
+		project:   func(c *ProjectConfig) any { return &c.Watch.IntervalMinutes },
+		global:    func(g *GlobalConfig) any { return &g.Watch.IntervalMinutes },
+		inherited: func(c *ProjectConfig) bool { return c.Watch.IntervalMinutes == 0 },
+	},
+	{
+		key:    "ui.no_color",
+		global: func(g *GlobalConfig) any { return &g.UI.NoColor },
+	},
+	{
+		key:    "ui.hide_progress",
+		global: func(g *GlobalConfig) any { return &g.UI.HideProgress },
+	},
+}
+
+func retentionSetting(key string, field func(*RetentionPolicy) any) setting {
+	return setting{
+		key:       key,
+		project:   func(c *ProjectConfig) any { return field(&c.Retention) },
+		global:    func(g *GlobalConfig) any { return field(&g.Retention) },
+		inherited: func(c *ProjectConfig) bool { return c.Retention.IsEmpty() },
+	}
+}
+
+func autoRetentionSetting(key string, field func(*RetentionPolicy) any) setting {
+	return setting{
+		key:       key,
+		project:   func(c *ProjectConfig) any { return field(&c.AutoRetention) },
+		global:    func(g *GlobalConfig) any { return field(&g.AutoRetention) },
+		inherited: func(c *ProjectConfig) bool { return c.AutoRetention.IsEmpty() },
+	}
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		for _, allowed := range values {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("недопустимое значение '%s' (%s)", value, strings.Join(values, ", "))
+	}
+}
+
+type SettingValue struct {
+	Key   string
+	Value string
+	Layer string
+}
+
+func SettingKeys(global bool) []string {
+	var keys []string
+	for _, s := range settings {
+		if (global && s.global != nil) || (!global && s.project != nil) {
+			keys = append(keys, s.key)
+		}
+	}
+	return keys
+}
+
+func findSetting(key string) (*setting, error) {
+	for i := range settings {
+		if settings[i].key == key {
+			return &settings[i], nil
+		}
+	}
+	return nil, fmt.Errorf("неизвестный параметр: %s", key)
+}
+
+func (g *GlobalConfig) GetSetting(key string) (string, error) {
+	s, err := findSetting(key)
+	if err != nil {
+		return "", err
+	}
+	if s.global == nil {
+		return "", fmt.Errorf("параметр %s задается только в конфигурации проекта", key)
+	}
+	return formatSetting(s.global(g)), nil
+}
+
+func (g *GlobalConfig) SetSetting(key string, value string) error {
+	s, err := findSetting(key)
+	if err != nil {
+		return err
+	}
+	if s.global == nil {
+		return fmt.Errorf("параметр %s задается только в конфигурации проекта", key)
+	}
+	if err := parseSetting(s, s.global(g), value); err != nil {
+		return err
+	}
+	if g.DefaultExcludes == nil {
+		g.DefaultExcludes = GetDefaultExcludes()
+	}
+	return nil
+}
+
+func (c *ProjectConfig) SetSetting(key string, value string) error {
+	s, err := findSetting(key)
+	if err != nil {
+		return err
+	}
+	if s.project == nil {
+		return fmt.Errorf("параметр %s задается только в глобальной конфигурации (--global)", key)
+	}
+	return parseSetting(s, s.project(c), value)
+}
+
+func (c *ProjectConfig) GetSetting(key string) (SettingValue, error) {
+	s, err := findSetting(key)
+	if err != nil {
+		return SettingValue{}, err
+	}
+	if s.project == nil {
+		return SettingValue{}, fmt.Errorf("параметр %s задается только в глобальной конфигурации (--global)", key)
+	}
+	return c.effectiveSetting(s), nil
+}
+
+func (c *ProjectConfig) Settings() []SettingValue {
+	var values []SettingValue
+	for i := range settings {
+		if settings[i].project != nil {
+			values = append(values, c.effectiveSetting(&settings[i]))
+		}
+	}
+	return values
+}
+
+func (c *ProjectConfig) effectiveSetting(s *setting) SettingValue {
+	if s.inherited == nil || !s.inherited(c) {
+		return SettingValue{Key: s.key, Value: formatSetting(s.project(c)), Layer: LayerProject}
+	}
+
+	value := formatSetting(s.global(c.Global()))
+	if value == "" || value == "0" {
+		switch s.key {
+		case "storage":
+			return SettingValue{Key: s.key, Value: StorageArchive, Layer: LayerDefault}
+		case "compression":
+			return SettingValue{Key: s.key, Value: CompressionDefault, Layer: LayerDefault}
+		case "format":
+			return SettingValue{Key: s.key, Value: FormatZip, Layer: LayerDefault}
+		case "symlinks":
+			return SettingValue{Key: s.key, Value: SymlinksPreserve, Layer: LayerDefault}
+		case "parallelism":
+			return SettingValue{Key: s.key, Value: strconv.Itoa(runtime.NumCPU()), Layer: LayerDefault}
+		case "restore.max_size_mb":
+			return SettingValue{Key: s.key, Value: strconv.Itoa(DefaultRestoreMaxSizeMB), Layer: LayerDefault}
+		case "restore.max_entries":
+			return SettingValue{Key: s.key, Value: strconv.Itoa(DefaultRestoreMaxEntries), Layer: LayerDefault}
+		case "watch.quiet_seconds":
+			return SettingValue{Key: s.key, Value: strconv.Itoa(DefaultWatchQuietSeconds), Layer: LayerDefault}
+		case "watch.interval_minutes":
+			return SettingValue{Key: s.key, Value: strconv.Itoa(DefaultWatchIntervalMinutes), Layer: LayerDefault}
+		}
+		if strings.HasPrefix(s.key, "auto_retention.") && c.Global().AutoRetention.IsEmpty() {
+			defaults := c.EffectiveAutoRetention()
+			return SettingValue{Key: s.key, Value: formatSetting(s.global(&GlobalConfig{AutoRetention: defaults})), Layer: LayerDefault}
+		}
+	}
+	return SettingValue{Key: s.key, Value: value, Layer: LayerGlobal}
+}
+
+func formatSetting(field any) string {
+	switch v := field.(type) {
+	case *string:
+		return *v
+	case *bool:
+		return strconv.FormatBool(*v)
+	case *int:
+		return strconv.Itoa(*v)
+	case *int64:
+		return strconv.FormatInt(*v, 10)
+	case *[]string:
+		return strings.Join(*v, ",")
+	}
+	return ""
+}
+
+func parseSetting(s *setting, field any, value string) error {
+	value = strings.TrimSpace(value)
+
+	if s.validate != nil && value != "" {
+		if err := s.validate(value); err != nil {
+			return fmt.Errorf("%s: %v", s.key, err)
+		}
+	}
+
+	switch v := field.(type) {
+	case *string:
+		*v = value
+	case *bool:
+		if value == "" {
+			*v = false
+			return nil
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: ожидается true или false", s.key)
+		}
+		*v = parsed
+	case *int:
+		if value == "" {
+			*v = 0
+			return nil
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("%s: ожидается неотрицательное число", s.key)
+		}
+		*v = parsed
+	case *int64:
+		if value == "" {
+			*v = 0
+			return nil
+		}
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("%s: ожидается неотрицательное число", s.key)
+		}
+		*v = parsed
+	case *[]string:
+		if value == "" {
+			*v = nil
+			return nil
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*v = items
+	}
+
+	return nil
+}
+
+func (g *GlobalConfig) Settings() []SettingValue {
+	var values []SettingValue
+	for i := range settings {
+		if settings[i].global != nil {
+			values = append(values, SettingValue{
+				Key:   settings[i].key,
+				Value: formatSetting(settings[i].global(g)),
+				Layer: LayerGlobal,
+			})
+		}
+	}
+	return values
+}
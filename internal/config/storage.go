@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const StorageRootEnv = "BACKUP_HOME"
+
+var storageRootOverride string
+
+func SetStorageRoot(path string) {
+	storageRootOverride = path
+}
+
+func GetStorageRoot() (string, error) {
+	root, _, err := resolveStorageRoot()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("не удалось создать директорию бэкапов: %v", err)
+	}
+
+	return root, nil
+}
+
+func resolveStorageRoot() (string, bool, error) {
+	for _, override := range []string{storageRootOverride, os.Getenv(StorageRootEnv)} {
+		if override == "" {
+			continue
+		}
+		root, err := filepath.Abs(override)
+		if err != nil {
+			return "", false, fmt.Errorf("некорректный путь хранилища %s: %v", override, err)
+		}
+		return root, true, nil
+	}
+
+	root, err := defaultStorageRoot()
+	return root, false, err
+}
+
+func defaultStorageRoot() (string, error) {
+	if runtime.GOOS == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, AppDataDir), nil
+		}
+	}
+
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+			return filepath.Join(dataHome, AppDataDir), nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("не удалось определить директорию хранилища (задайте %s): %v", StorageRootEnv, err)
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(home, "AppData", "Roaming", AppDataDir), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", AppDataDir), nil
+	default:
+		return filepath.Join(home, ".local", "share", AppDataDir), nil
+	}
+}
+
+func (c *ProjectConfig) resolveBackupPath() error {
+	root, overridden, err := resolveStorageRoot()
+	if err != nil {
+		return err
+	}
+
+	expected := filepath.Join(root, c.ID)
+	if c.BackupPath == expected {
+		return nil
+	}
+
+	if !overridden && c.BackupPath != "" {
+		if isDir(c.BackupPath) {
+			return nil
+		}
+		c.missingBackupPath = c.BackupPath
+	}
+
+	if err := os.MkdirAll(expected, 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию проекта: %v", err)
+	}
+
+	c.recordedBackupPath = c.BackupPath
+	c.resolvedBackupPath = expected
+	c.BackupPath = expected
+	return nil
+}
+
+func (c *ProjectConfig) MissingBackupPath() string {
+	return c.missingBackupPath
+}
+
+func (c *ProjectConfig) AdoptBackupPath() {
+	c.missingBackupPath = ""
+	c.recordedBackupPath = ""
+	c.resolvedBackupPath = ""
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
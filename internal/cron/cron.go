@@ -0,0 +1,272 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MinInterval = time.Minute
+	searchYears = 5
+)
+
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+type Every struct {
+	Interval time.Duration
+}
+
+func (e Every) Next(after time.Time) time.Time {
+	return after.Add(e.Interval).Truncate(time.Second)
+}
+
+type Expression struct {
+	minute, hour, day, month, weekday uint64
+
+	anyHour, anyDay, anyWeekday bool
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("пустое расписание")
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every"); ok {
+		return parseEvery(strings.TrimSpace(rest))
+	}
+	if interval, err := time.ParseDuration(spec); err == nil {
+		return newEvery(interval)
+	}
+	if expanded, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("неизвестное расписание %s", spec)
+	}
+
+	return parseExpression(spec)
+}
+
+func parseEvery(value string) (Schedule, error) {
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный интервал %q: %v", value, err)
+	}
+	return newEvery(interval)
+}
+
+func newEvery(interval time.Duration) (Schedule, error) {
+	if interval < MinInterval {
+		return nil, fmt.Errorf("интервал должен быть не меньше %s", MinInterval)
+	}
+	return Every{Interval: interval}, nil
+}
+
+func parseExpression(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron-выражение должно содержать 5 полей (минута час день месяц день_недели), получено %d", len(fields))
+	}
+
+	e := &Expression{}
+	var err error
+
+	if e.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("минуты: %v", err)
+	}
+	if e.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("часы: %v", err)
+	}
+	if e.day, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("день месяца: %v", err)
+	}
+	if e.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("месяц: %v", err)
+	}
+	if e.weekday, err = parseField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("день недели: %v", err)
+	}
+	if e.weekday&(1<<7) != 0 {
+		e.weekday |= 1
+	}
+
+	e.anyHour = strings.HasPrefix(fields[1], "*")
+	e.anyDay = strings.HasPrefix(fields[2], "*")
+	e.anyWeekday = strings.HasPrefix(fields[4], "*")
+
+	if e.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("расписание %q никогда не срабатывает", spec)
+	}
+
+	return e, nil
+}
+
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value < 1 {
+				return 0, fmt.Errorf("некорректный шаг %q", stepPart)
+			}
+			step = value
+		}
+
+		var low, high int
+		switch {
+		case rangePart == "*":
+			low, high = min, max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(from, min, max, names); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(to, min, max, names); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("некорректный диапазон %q", rangePart)
+			}
+		default:
+			value, err := parseValue(rangePart, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			low, high = value, value
+			if hasStep {
+				high = max
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(value string, min, max int, names map[string]int) (int, error) {
+	if named, ok := names[strings.ToLower(value)]; ok {
+		return named, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("некорректное значение %q", value)
+	}
+	if number < min || number > max {
+		return 0, fmt.Errorf("значение %d вне диапазона %d-%d", number, min, max)
+	}
+	return number, nil
+}
+
+func (e *Expression) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(searchYears, 0, 0)
+
+	for t.Before(limit) {
+		if !e.anyHour && e.matchesSkipped(t) {
+			return t
+		}
+		if e.month&(1<<uint(t.Month())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()))
+			continue
+		}
+		if !e.matchesDay(t) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()))
+			continue
+		}
+		if e.hour&(1<<uint(t.Hour())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location()))
+			continue
+		}
+		if e.minute&(1<<uint(t.Minute())) == 0 || (!e.anyHour && repeatedWallClock(t)) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func forward(t time.Time, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+func (e *Expression) matches(wall time.Time) bool {
+	return e.month&(1<<uint(wall.Month())) != 0 &&
+		e.matchesDay(wall) &&
+		e.hour&(1<<uint(wall.Hour())) != 0 &&
+		e.minute&(1<<uint(wall.Minute())) != 0
+}
+
+func (e *Expression) matchesSkipped(t time.Time) bool {
+	_, before := t.Add(-time.Minute).Zone()
+	_, offset := t.Zone()
+	gap := time.Duration(offset-before) * time.Second
+	if gap <= 0 {
+		return false
+	}
+
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	for skipped := wall.Add(-gap); skipped.Before(wall); skipped = skipped.Add(time.Minute) {
+		if e.matches(skipped) {
+			return true
+		}
+	}
+	return false
+}
+
+func repeatedWallClock(t time.Time) bool {
+	_, offset := t.Zone()
+	for _, back := range []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour} {
+		_, before := t.Add(-back).Zone()
+		if time.Duration(before-offset)*time.Second == back {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Expression) matchesDay(t time.Time) bool {
+	day := e.day&(1<<uint(t.Day())) != 0
+	weekday := e.weekday&(1<<uint(t.Weekday())) != 0
+
+	if e.anyDay || e.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
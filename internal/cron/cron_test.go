@@ -0,0 +1,243 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+func nextTimes(schedule Schedule, after time.Time, count int) []time.Time {
+	var times []time.Time
+	for range count {
+		after = schedule.Next(after)
+		times = append(times, after)
+	}
+	return times
+}
+
+func checkNext(t *testing.T, spec string, after time.Time, want []string) {
+	t.Helper()
+
+	schedule, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", spec, err)
+	}
+
+	got := nextTimes(schedule, after, len(want))
+	for i := range want {
+		if formatted := got[i].Format("2006-01-02 15:04 MST Mon"); formatted != want[i] {
+			t.Errorf("%q run %d after %s: got %s, want %s", spec, i+1, after.Format(time.RFC3339), formatted, want[i])
+		}
+	}
+}
+
+func TestExpressionNext(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		want []string
+	}{
+		{"every minute", "* * * * *", []string{
+			"2024-01-01 00:01 UTC Mon", "2024-01-01 00:02 UTC Mon",
+		}},
+		{"fixed time", "30 4 * * *", []string{
+			"2024-01-01 04:30 UTC Mon", "2024-01-02 04:30 UTC Tue",
+		}},
+		{"minute range", "10-12 3 * * *", []string{
+			"2024-01-01 03:10 UTC Mon", "2024-01-01 03:11 UTC Mon", "2024-01-01 03:12 UTC Mon", "2024-01-02 03:10 UTC Tue",
+		}},
+		{"step over all values", "*/20 * * * *", []string{
+			"2024-01-01 00:20 UTC Mon", "2024-01-01 00:40 UTC Mon", "2024-01-01 01:00 UTC Mon",
+		}},
+		{"step over a range", "0 8-17/4 * * *", []string{
+			"2024-01-01 08:00 UTC Mon", "2024-01-01 12:00 UTC Mon", "2024-01-01 16:00 UTC Mon", "2024-01-02 08:00 UTC Tue",
+		}},
+		{"step from a value", "0 20/2 * * *", []string{
+			"2024-01-01 20:00 UTC Mon", "2024-01-01 22:00 UTC Mon", "2024-01-02 20:00 UTC Tue",
+		}},
+		{"list", "0 9,13,18 * * *", []string{
+			"2024-01-01 09:00 UTC Mon", "2024-01-01 13:00 UTC Mon", "2024-01-01 18:00 UTC Mon",
+		}},
+		{"list of ranges and steps", "0,45-46 */12 * * *", []string{
+			"2024-01-01 00:45 UTC Mon", "2024-01-01 00:46 UTC Mon", "2024-01-01 12:00 UTC Mon",
+		}},
+		{"month names", "0 0 1 mar,Sep *", []string{
+			"2024-03-01 00:00 UTC Fri", "2024-09-01 00:00 UTC Sun", "2025-03-01 00:00 UTC Sat",
+		}},
+		{"month name range", "0 0 15 NOV-dec *", []string{
+			"2024-11-15 00:00 UTC Fri", "2024-12-15 00:00 UTC Sun", "2025-11-15 00:00 UTC Sat",
+		}},
+		{"weekday names", "0 6 * * mon-wed", []string{
+			"2024-01-01 06:00 UTC Mon", "2024-01-02 06:00 UTC Tue", "2024-01-03 06:00 UTC Wed", "2024-01-08 06:00 UTC Mon",
+		}},
+		{"zero is Sunday", "0 0 * * 0", []string{
+			"2024-01-07 00:00 UTC Sun", "2024-01-14 00:00 UTC Sun",
+		}},
+		{"seven is Sunday", "0 0 * * 7", []string{
+			"2024-01-07 00:00 UTC Sun", "2024-01-14 00:00 UTC Sun",
+		}},
+		{"range ending with seven", "0 0 * * 5-7", []string{
+			"2024-01-05 00:00 UTC Fri", "2024-01-06 00:00 UTC Sat", "2024-01-07 00:00 UTC Sun", "2024-01-12 00:00 UTC Fri",
+		}},
+		{"day of month only", "0 0 13 * *", []string{
+			"2024-01-13 00:00 UTC Sat", "2024-02-13 00:00 UTC Tue",
+		}},
+		{"day of month or weekday", "0 0 13 * fri", []string{
+			"2024-01-05 00:00 UTC Fri", "2024-01-12 00:00 UTC Fri", "2024-01-13 00:00 UTC Sat", "2024-01-19 00:00 UTC Fri",
+		}},
+		{"wildcard weekday restricts nothing", "0 0 13 * *", []string{
+			"2024-01-13 00:00 UTC Sat",
+		}},
+		{"stepped wildcard day of month combines with weekday", "0 0 */10 * mon", []string{
+			"2024-03-11 00:00 UTC Mon", "2024-04-01 00:00 UTC Mon", "2024-07-01 00:00 UTC Mon",
+		}},
+		{"day missing from most months", "0 0 31 * *", []string{
+			"2024-01-31 00:00 UTC Wed", "2024-03-31 00:00 UTC Sun", "2024-05-31 00:00 UTC Fri",
+		}},
+		{"leap day", "0 0 29 2 *", []string{
+			"2024-02-29 00:00 UTC Thu", "2028-02-29 00:00 UTC Tue",
+		}},
+		{"descriptor", "@weekly", []string{
+			"2024-01-07 00:00 UTC Sun", "2024-01-14 00:00 UTC Sun",
+		}},
+		{"descriptor is case-insensitive", "@Monthly", []string{
+			"2024-02-01 00:00 UTC Thu", "2024-03-01 00:00 UTC Fri",
+		}},
+		{"interval", "@every 90m", []string{
+			"2024-01-01 01:30 UTC Mon", "2024-01-01 03:00 UTC Mon",
+		}},
+		{"bare duration", "2h", []string{
+			"2024-01-01 02:00 UTC Mon", "2024-01-01 04:00 UTC Mon",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkNext(t, test.spec, start, test.want)
+		})
+	}
+}
+
+func TestNextStartsAfterGivenTime(t *testing.T) {
+	checkNext(t, "30 4 * * *", time.Date(2024, 1, 1, 4, 30, 0, 0, time.UTC), []string{"2024-01-02 04:30 UTC Tue"})
+	checkNext(t, "30 4 * * *", time.Date(2024, 1, 1, 4, 29, 59, 0, time.UTC), []string{"2024-01-01 04:30 UTC Mon"})
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		spec  string
+		error string
+	}{
+		{"", "пустое расписание"},
+		{"   ", "пустое расписание"},
+		{"* * * *", "5 полей"},
+		{"* * * * * *", "5 полей"},
+		{"60 * * * *", "минуты"},
+		{"-1 * * * *", "минуты"},
+		{"* 24 * * *", "часы"},
+		{"* * 0 * *", "день месяца"},
+		{"* * 32 * *", "день месяца"},
+		{"* * * 13 *", "месяц"},
+		{"* * * foo *", "месяц"},
+		{"* * * * 8", "день недели"},
+		{"* * * * sunday", "день недели"},
+		{"5-1 * * * *", "некорректный диапазон"},
+		{"*/0 * * * *", "некорректный шаг"},
+		{"*/x * * * *", "некорректный шаг"},
+		{"1,,2 * * * *", "минуты"},
+		{"0 0 30 2 *", "никогда не срабатывает"},
+		{"0 0 31 4,6,9,11 *", "никогда не срабатывает"},
+		{"@often", "неизвестное расписание"},
+		{"@every", "некорректный интервал"},
+		{"@every soon", "некорректный интервал"},
+		{"@every 30s", "не меньше"},
+		{"59s", "не меньше"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			_, err := Parse(test.spec)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded", test.spec)
+			}
+			if !strings.Contains(err.Error(), test.error) {
+				t.Errorf("Parse(%q) = %q, want it to mention %q", test.spec, err, test.error)
+			}
+		})
+	}
+}
+
+func TestExpressionNextAcrossDST(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	lordHowe := mustLocation(t, "Australia/Lord_Howe")
+	havana := mustLocation(t, "America/Havana")
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  []string
+	}{
+		{"daily job in the skipped hour runs when the clock jumps", "30 2 * * *", time.Date(2024, 3, 9, 12, 0, 0, 0, newYork), []string{
+			"2024-03-10 03:00 EDT Sun", "2024-03-11 02:30 EDT Mon",
+		}},
+		{"daily job after the skipped hour keeps its time", "30 3 * * *", time.Date(2024, 3, 9, 12, 0, 0, 0, newYork), []string{
+			"2024-03-10 03:30 EDT Sun", "2024-03-11 03:30 EDT Mon",
+		}},
+		{"daily job on another day is not pulled into the gap", "30 2 * * mon", time.Date(2024, 3, 9, 12, 0, 0, 0, newYork), []string{
+			"2024-03-11 02:30 EDT Mon",
+		}},
+		{"hourly job skips the missing hour", "15 * * * *", time.Date(2024, 3, 10, 1, 0, 0, 0, newYork), []string{
+			"2024-03-10 01:15 EST Sun", "2024-03-10 03:15 EDT Sun", "2024-03-10 04:15 EDT Sun",
+		}},
+		{"daily job in the repeated hour runs once", "30 1 * * *", time.Date(2024, 11, 2, 12, 0, 0, 0, newYork), []string{
+			"2024-11-03 01:30 EDT Sun", "2024-11-04 01:30 EST Mon",
+		}},
+		{"hourly job runs in both passes of the repeated hour", "15 * * * *", time.Date(2024, 11, 3, 0, 30, 0, 0, newYork), []string{
+			"2024-11-03 01:15 EDT Sun", "2024-11-03 01:15 EST Sun", "2024-11-03 02:15 EST Sun",
+		}},
+		{"minute steps keep real time through the repeated hour", "*/30 * * * *", time.Date(2024, 11, 3, 1, 0, 0, 0, newYork), []string{
+			"2024-11-03 01:30 EDT Sun", "2024-11-03 01:00 EST Sun", "2024-11-03 01:30 EST Sun", "2024-11-03 02:00 EST Sun",
+		}},
+		{"midnight job when midnight is skipped", "0 0 * * *", time.Date(2024, 3, 9, 12, 0, 0, 0, havana), []string{
+			"2024-03-10 01:00 CDT Sun", "2024-03-11 00:00 CDT Mon",
+		}},
+		{"half-hour shift forward", "15 2 * * *", time.Date(2024, 10, 5, 12, 0, 0, 0, lordHowe), []string{
+			"2024-10-06 02:30 +11 Sun", "2024-10-07 02:15 +11 Mon",
+		}},
+		{"half-hour shift back", "45 1 * * *", time.Date(2024, 4, 6, 12, 0, 0, 0, lordHowe), []string{
+			"2024-04-07 01:45 +11 Sun", "2024-04-08 01:45 +1030 Mon",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkNext(t, test.spec, test.after, test.want)
+		})
+	}
+}
+
+func TestEveryIgnoresDST(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	after := time.Date(2024, 3, 10, 1, 30, 0, 0, newYork)
+
+	schedule, err := Parse("@every 1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := schedule.Next(after); next.Sub(after) != time.Hour {
+		t.Errorf("Next(%s) = %s, want exactly one hour later", after, next)
+	}
+}
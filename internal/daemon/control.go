@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"backup-tool/internal/config"
+)
+
+const (
+	SocketFileName = "daemon.sock"
+	requestTimeout = 5 * time.Second
+)
+
+const (
+	CommandStatus = "status"
+	CommandReload = "reload"
+	CommandStop   = "stop"
+)
+
+var ErrNotRunning = errors.New("демон не запущен")
+
+type request struct {
+	Command string `json:"command"`
+}
+
+type response struct {
+	Status *Status `json:"status,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+func SocketPath() (string, error) {
+	storageRoot, err := config.GetStorageRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(storageRoot, SocketFileName), nil
+}
+
+func Send(command string) (*Status, error) {
+	socketPath, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+	return send(socketPath, command)
+}
+
+func send(socketPath, command string) (*Status, error) {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if err := json.NewEncoder(conn).Encode(request{Command: command}); err != nil {
+		return nil, fmt.Errorf("не удалось отправить команду демону: %v", err)
+	}
+
+	var reply response
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("не удалось получить ответ демона: %v", err)
+	}
+	if reply.Error != "" {
+		return nil, errors.New(reply.Error)
+	}
+
+	return reply.Status, nil
+}
+
+func listen() (net.Listener, error) {
+	socketPath, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := send(socketPath, CommandStatus); err == nil {
+		return nil, fmt.Errorf("демон уже запущен (%s)", socketPath)
+	}
+	os.Remove(socketPath)
+
+	listener, err := listenSocket(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть управляющий сокет %s: %v", socketPath, err)
+	}
+
+	return listener, nil
+}
+
+func (d *Daemon) serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		d.listener.Close()
+	}()
+
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *Daemon) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var command request
+	if err := json.NewDecoder(conn).Decode(&command); err != nil {
+		return
+	}
+
+	var reply response
+	switch command.Command {
+	case CommandStatus:
+		reply.Status = d.Status()
+	case CommandReload:
+		select {
+		case d.reload <- struct{}{}:
+		default:
+		}
+		reply.Status = d.Status()
+	case CommandStop:
+		reply.Status = d.Status()
+		d.cancel()
+	default:
+		reply.Error = fmt.Sprintf("неизвестная команда %s", command.Command)
+	}
+
+	json.NewEncoder(conn).Encode(reply)
+}
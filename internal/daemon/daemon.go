@@ -0,0 +1,259 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"backup-tool/internal/backup"
+	"backup-tool/internal/config"
+	"backup-tool/internal/cron"
+)
+
+const maxWait = time.Minute
+
+type Options struct {
+	OnRun    func(job Job, run *backup.ScheduleRun)
+	OnReload func(projects, schedules int)
+	OnError  func(err error)
+}
+
+type Job struct {
+	ProjectID   string              `json:"project_id"`
+	ProjectName string              `json:"project_name"`
+	ProjectPath string              `json:"project_path"`
+	ScheduleID  string              `json:"schedule_id"`
+	Spec        string              `json:"spec"`
+	Next        time.Time           `json:"next"`
+	Running     bool                `json:"running,omitempty"`
+	LastRun     *backup.ScheduleRun `json:"last_run,omitempty"`
+
+	schedule cron.Schedule
+}
+
+type Status struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	Jobs      []Job     `json:"jobs"`
+}
+
+type Daemon struct {
+	options  Options
+	listener net.Listener
+	started  time.Time
+	reload   chan struct{}
+	cancel   context.CancelFunc
+
+	mu   sync.Mutex
+	jobs []*Job
+}
+
+func New(options Options) (*Daemon, error) {
+	listener, err := listen()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Daemon{
+		options:  options,
+		listener: listener,
+		reload:   make(chan struct{}, 1),
+	}, nil
+}
+
+func (d *Daemon) Close() error {
+	return d.listener.Close()
+}
+
+func (d *Daemon) Run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	d.started = time.Now()
+	d.cancel = cancel
+	go d.serve(ctx)
+
+	d.load()
+
+	timer := time.NewTimer(d.untilNext())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.reload:
+			d.load()
+		case <-timer.C:
+			d.runDue(ctx)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d.untilNext())
+	}
+}
+
+func (d *Daemon) Status() *Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := &Status{PID: os.Getpid(), StartedAt: d.started}
+	for _, job := range d.jobs {
+		status.Jobs = append(status.Jobs, *job)
+	}
+	return status
+}
+
+func (d *Daemon) load() {
+	projects, err := config.LoadRegisteredProjects()
+	if err != nil {
+		d.reportError(err)
+		return
+	}
+
+	d.mu.Lock()
+	previous := make(map[string]*Job, len(d.jobs))
+	for _, job := range d.jobs {
+		previous[job.ProjectID+"/"+job.ScheduleID] = job
+	}
+	d.mu.Unlock()
+
+	var jobs []*Job
+	scheduled := 0
+
+	for _, project := range projects {
+		projectConfig, err := config.LoadProjectConfig(project.Path)
+		if err != nil {
+			d.reportError(fmt.Errorf("проект %s: %v", project.Path, err))
+			continue
+		}
+		if projectConfig.ID != project.ID {
+			d.reportError(fmt.Errorf("проект %s: в директории теперь другой проект", project.Path))
+			continue
+		}
+		if len(projectConfig.Schedules) == 0 {
+			continue
+		}
+		if missing := projectConfig.MissingBackupPath(); missing != "" {
+			d.reportError(fmt.Errorf("проект %s: записанный путь бэкапов %s не существует, используется %s", project.Path, missing, projectConfig.BackupPath))
+		}
+
+		history, err := backup.LoadScheduleHistory(project.Path)
+		if err != nil {
+			d.reportError(fmt.Errorf("проект %s: %v", project.Path, err))
+		}
+
+		scheduled++
+		for _, entry := range projectConfig.Schedules {
+			parsed, err := cron.Parse(entry.Spec)
+			if err != nil {
+				d.reportError(fmt.Errorf("проект %s, расписание %s: %v", project.Path, entry.ID, err))
+				continue
+			}
+
+			job := &Job{
+				ProjectID:   project.ID,
+				ProjectName: projectConfig.Name,
+				ProjectPath: project.Path,
+				ScheduleID:  entry.ID,
+				Spec:        entry.Spec,
+				LastRun:     backup.LastScheduleRun(history, entry.ID),
+				schedule:    parsed,
+			}
+
+			if prev, ok := previous[project.ID+"/"+entry.ID]; ok && prev.Spec == entry.Spec {
+				job.Next = prev.Next
+			} else if job.LastRun != nil {
+				job.Next = parsed.Next(job.LastRun.StartedAt)
+			} else {
+				job.Next = parsed.Next(d.started)
+			}
+
+			jobs = append(jobs, job)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Next.Before(jobs[j].Next)
+	})
+
+	d.mu.Lock()
+	d.jobs = jobs
+	d.mu.Unlock()
+
+	if d.options.OnReload != nil {
+		d.options.OnReload(scheduled, len(jobs))
+	}
+}
+
+func (d *Daemon) runDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		job := d.nextDue(time.Now())
+		if job == nil {
+			return
+		}
+
+		run, err := backup.RunScheduledBackup(ctx, job.ProjectPath, config.Schedule{ID: job.ScheduleID, Spec: job.Spec})
+		if err != nil {
+			d.reportError(fmt.Errorf("проект %s: %v", job.ProjectPath, err))
+		}
+
+		d.mu.Lock()
+		job.Running = false
+		job.LastRun = run
+		job.Next = job.schedule.Next(time.Now())
+		snapshot := *job
+		d.mu.Unlock()
+
+		if d.options.OnRun != nil {
+			d.options.OnRun(snapshot, run)
+		}
+	}
+}
+
+func (d *Daemon) nextDue(now time.Time) *Job {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var due *Job
+	for _, job := range d.jobs {
+		if job.Next.After(now) {
+			continue
+		}
+		if due == nil || job.Next.Before(due.Next) {
+			due = job
+		}
+	}
+	if due != nil {
+		due.Running = true
+	}
+	return due
+}
+
+func (d *Daemon) untilNext() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	wait := maxWait
+	for _, job := range d.jobs {
+		if until := time.Until(job.Next); until < wait {
+			wait = until
+		}
+	}
+	return max(wait, 0)
+}
+
+func (d *Daemon) reportError(err error) {
+	if d.options.OnError != nil {
+		d.options.OnError(err)
+	}
+}
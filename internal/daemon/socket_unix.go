@@ -0,0 +1,14 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"net"
+	"syscall"
+)
+
+func listenSocket(socketPath string) (net.Listener, error) {
+	umask := syscall.Umask(0077)
+	defer syscall.Umask(umask)
+	return net.Listen("unix", socketPath)
+}
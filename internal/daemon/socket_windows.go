@@ -0,0 +1,9 @@
+//go:build windows
+
+package daemon
+
+import "net"
+
+func listenSocket(socketPath string) (net.Listener, error) {
+	return net.Listen("unix", socketPath)
+}
@@ -0,0 +1,291 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	Magic      = "BKPENC\x00\x01"
+	SaltSize   = 16
+	KeySize    = 32
+	ChunkSize  = 64 * 1024
+	HeaderSize = len(Magic) + SaltSize + noncePrefixSize
+
+	noncePrefixSize = 8
+	tagSize         = 16
+	sealedChunkSize = ChunkSize + tagSize
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var ErrAuthentication = errors.New("неверный ключ шифрования или данные повреждены")
+
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func DeriveKey(passphrase string, salt []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("пустой пароль")
+	}
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, KeySize)
+}
+
+func KeyCheck(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("backup-tool key check"))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func ReadHeader(r io.ReaderAt) (salt []byte, ok bool) {
+	header := make([]byte, HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, false
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return nil, false
+	}
+	return header[len(Magic) : len(Magic)+SaltSize], true
+}
+
+func IsEncrypted(data []byte) bool {
+	return len(data) >= HeaderSize && string(data[:len(Magic)]) == Magic
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, index uint64) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], uint32(index))
+	return nonce
+}
+
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+type Writer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	prefix []byte
+	buf    []byte
+	index  uint64
+	closed bool
+}
+
+func NewWriter(w io.Writer, key []byte, salt []byte) (*Writer, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, Magic...)
+	header = append(header, salt...)
+	header = append(header, prefix...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, aead: aead, prefix: prefix, buf: make([]byte, 0, ChunkSize)}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("запись в закрытый поток")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		if len(w.buf) == ChunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (w *Writer) seal(final bool) error {
+	if w.index > 1<<32-1 {
+		return fmt.Errorf("слишком большой поток для шифрования")
+	}
+	sealed := w.aead.Seal(nil, chunkNonce(w.prefix, w.index), w.buf, chunkAAD(final))
+	w.index++
+	w.buf = w.buf[:0]
+	_, err := w.w.Write(sealed)
+	return err
+}
+
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.seal(true)
+}
+
+type ReaderAt struct {
+	r      io.ReaderAt
+	aead   cipher.AEAD
+	prefix []byte
+	size   int64
+	chunks int64
+	body   int64
+
+	cached      int64
+	cachedPlain []byte
+}
+
+func NewReaderAt(r io.ReaderAt, size int64, key []byte) (*ReaderAt, error) {
+	if size < int64(HeaderSize+tagSize) {
+		return nil, ErrAuthentication
+	}
+
+	header := make([]byte, HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return nil, fmt.Errorf("данные не зашифрованы")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	body := size - int64(HeaderSize)
+	chunks := (body + sealedChunkSize - 1) / sealedChunkSize
+	plainSize := body - chunks*tagSize
+	if plainSize < 0 {
+		return nil, ErrAuthentication
+	}
+
+	reader := &ReaderAt{
+		r:      r,
+		aead:   aead,
+		prefix: header[len(Magic)+SaltSize:],
+		size:   plainSize,
+		chunks: chunks,
+		body:   body,
+		cached: -1,
+	}
+
+	if _, err := reader.chunk(chunks - 1); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+func (r *ReaderAt) Size() int64 {
+	return r.size
+}
+
+func (r *ReaderAt) chunk(index int64) ([]byte, error) {
+	if index == r.cached {
+		return r.cachedPlain, nil
+	}
+
+	offset := int64(index) * sealedChunkSize
+	length := int64(sealedChunkSize)
+	if offset+length > r.body {
+		length = r.body - offset
+	}
+
+	sealed := make([]byte, length)
+	if _, err := r.r.ReadAt(sealed, int64(HeaderSize)+offset); err != nil && !(err == io.EOF && int64(len(sealed)) == length) {
+		return nil, err
+	}
+
+	plain, err := r.aead.Open(nil, chunkNonce(r.prefix, uint64(index)), sealed, chunkAAD(index == r.chunks-1))
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+
+	r.cached, r.cachedPlain = index, plain
+	return plain, nil
+}
+
+func (r *ReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	read := 0
+	for read < len(p) && off < r.size {
+		plain, err := r.chunk(off / ChunkSize)
+		if err != nil {
+			return read, err
+		}
+		n := copy(p[read:], plain[off%ChunkSize:])
+		read += n
+		off += int64(n)
+	}
+
+	if read < len(p) {
+		return read, io.EOF
+	}
+	return read, nil
+}
+
+func Encrypt(data []byte, key []byte, salt []byte) ([]byte, error) {
+	var out bytes.Buffer
+	writer, err := NewWriter(&out, key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func Decrypt(data []byte, key []byte) ([]byte, error) {
+	reader, err := NewReaderAt(bytes.NewReader(data), int64(len(data)), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+}
@@ -0,0 +1,327 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	BackupIgnoreFile = ".backupignore"
+	GitIgnoreFile    = ".gitignore"
+)
+
+type Rule struct {
+	Pattern string
+	Source  string
+	Line    int
+
+	base     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	regex    *regexp.Regexp
+}
+
+type Match struct {
+	Excluded bool
+	Rule     *Rule
+}
+
+type Options struct {
+	UseGitignore bool
+}
+
+type Matcher struct {
+	root    string
+	options Options
+	rules   []*Rule
+
+	mu      sync.Mutex
+	loaded  map[string][]*Rule
+	invalid []error
+}
+
+func New(root string, options Options) *Matcher {
+	return &Matcher{
+		root:    root,
+		options: options,
+		loaded:  make(map[string][]*Rule),
+	}
+}
+
+func (m *Matcher) AddPatterns(patterns []string, source string) {
+	for i, pattern := range patterns {
+		rule, err := ParseRule(pattern, "", source, i+1)
+		if err != nil {
+			m.invalid = append(m.invalid, err)
+			continue
+		}
+		if rule != nil {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+func (m *Matcher) Errors() []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]error(nil), m.invalid...)
+}
+
+func ParseRule(pattern string, base string, source string, line int) (*Rule, error) {
+	rule := &Rule{Pattern: pattern, Source: source, Line: line, base: base}
+
+	text := strings.TrimRight(pattern, "\r")
+	for strings.HasSuffix(text, " ") && !strings.HasSuffix(text, "\\ ") {
+		text = text[:len(text)-1]
+	}
+
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(text, "!") {
+		rule.negate = true
+		text = text[1:]
+	} else if strings.HasPrefix(text, "\\!") || strings.HasPrefix(text, "\\#") {
+		text = text[1:]
+	}
+
+	if strings.HasSuffix(text, "/") {
+		rule.dirOnly = true
+		text = strings.TrimRight(text, "/")
+	}
+
+	if text == "" {
+		return nil, nil
+	}
+
+	if strings.Contains(text, "/") {
+		rule.anchored = true
+		text = strings.TrimPrefix(text, "/")
+	}
+
+	expr := globToRegex(text)
+	if !rule.anchored {
+		expr = "(?:.*/)?" + expr
+	}
+
+	regex, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: некорректный шаблон %q: %v", source, line, pattern, err)
+	}
+	rule.regex = regex
+
+	return rule, nil
+}
+
+func globToRegex(glob string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				atEnd := i+2 == len(glob) || glob[i+2] == '/'
+				if atStart && atEnd {
+					if i+2 == len(glob) {
+						out.WriteString(".*")
+						i++
+					} else {
+						out.WriteString("(?:.*/)?")
+						i += 2
+					}
+					continue
+				}
+				out.WriteString("[^/]*")
+				i++
+				continue
+			}
+			out.WriteString("[^/]*")
+		case '?':
+			out.WriteString("[^/]")
+		case '[':
+			start := i + 1
+			if start < len(glob) && glob[start] == '!' {
+				start++
+			}
+			if start < len(glob) && glob[start] == ']' {
+				start++
+			}
+			end := strings.IndexByte(glob[start:], ']')
+			if end < 0 {
+				out.WriteString(`\[`)
+				continue
+			}
+			end += start
+
+			out.WriteByte('[')
+			class := glob[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				out.WriteByte('^')
+				class = class[1:]
+			}
+			for j := 0; j < len(class); j++ {
+				switch class[j] {
+				case '\\':
+					if j+1 < len(class) {
+						j++
+					}
+					out.WriteString(escapeClassChar(class[j]))
+				case '[', ']':
+					out.WriteString(escapeClassChar(class[j]))
+				default:
+					out.WriteByte(class[j])
+				}
+			}
+			out.WriteByte(']')
+			i = end
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				out.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			out.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return out.String()
+}
+
+func escapeClassChar(c byte) string {
+	if c >= 0x80 || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+		return string(c)
+	}
+	return `\` + string(c)
+}
+
+func (r *Rule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(relPath, r.base+"/") {
+			return false
+		}
+		relPath = relPath[len(r.base)+1:]
+	}
+
+	return r.regex.MatchString(relPath)
+}
+
+func (m *Matcher) Excluded(relPath string, isDir bool) bool {
+	return m.Match(relPath, isDir).Excluded
+}
+
+func (m *Matcher) Match(relPath string, isDir bool) Match {
+	relPath = strings.Trim(filepath.ToSlash(relPath), "/")
+	if relPath == "" || relPath == "." {
+		return Match{}
+	}
+
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if parent := m.matchSingle(strings.Join(parts[:i], "/"), true); parent.Excluded {
+			return parent
+		}
+	}
+
+	return m.matchSingle(relPath, isDir)
+}
+
+func (m *Matcher) MatchEntry(relPath string, isDir bool) Match {
+	return m.matchSingle(strings.Trim(filepath.ToSlash(relPath), "/"), isDir)
+}
+
+func (m *Matcher) matchSingle(relPath string, isDir bool) Match {
+	var result Match
+
+	for _, rule := range m.rules {
+		if rule.matches(relPath, isDir) {
+			result = Match{Excluded: !rule.negate, Rule: rule}
+		}
+	}
+
+	for _, dir := range parentDirs(relPath) {
+		for _, rule := range m.dirRules(dir) {
+			if rule.matches(relPath, isDir) {
+				result = Match{Excluded: !rule.negate, Rule: rule}
+			}
+		}
+	}
+
+	return result
+}
+
+func parentDirs(relPath string) []string {
+	dirs := []string{""}
+	dir := path.Dir(relPath)
+	if dir == "." {
+		return dirs
+	}
+
+	parts := strings.Split(dir, "/")
+	for i := range parts {
+		dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+	}
+	return dirs
+}
+
+func (m *Matcher) dirRules(dir string) []*Rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, ok := m.loaded[dir]; ok {
+		return rules
+	}
+
+	var rules []*Rule
+	names := []string{BackupIgnoreFile}
+	if m.options.UseGitignore {
+		names = []string{GitIgnoreFile, BackupIgnoreFile}
+	}
+	for _, name := range names {
+		loaded, invalid := loadIgnoreFile(m.root, dir, name)
+		rules = append(rules, loaded...)
+		m.invalid = append(m.invalid, invalid...)
+	}
+
+	m.loaded[dir] = rules
+	return rules
+}
+
+func loadIgnoreFile(root string, dir string, name string) ([]*Rule, []error) {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(dir), name))
+	if err != nil {
+		return nil, nil
+	}
+	defer file.Close()
+
+	source := path.Join(dir, name)
+
+	var rules []*Rule
+	var invalid []error
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		rule, err := ParseRule(scanner.Text(), dir, source, line)
+		if err != nil {
+			invalid = append(invalid, err)
+			continue
+		}
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, invalid
+}
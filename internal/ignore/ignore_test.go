@@ -0,0 +1,244 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type matchCase struct {
+	path     string
+	isDir    bool
+	excluded bool
+}
+
+func checkMatches(t *testing.T, matcher *Matcher, cases []matchCase) {
+	t.Helper()
+	for _, c := range cases {
+		if got := matcher.Excluded(c.path, c.isDir); got != c.excluded {
+			t.Errorf("Excluded(%q, dir=%v) = %v, want %v", c.path, c.isDir, got, c.excluded)
+		}
+	}
+}
+
+func writeFile(t *testing.T, root string, relPath string, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		cases    []matchCase
+	}{
+		{
+			name:     "unanchored name matches at any depth",
+			patterns: []string{"*.log"},
+			cases: []matchCase{
+				{"app.log", false, true},
+				{"logs/app.log", false, true},
+				{"app.log.txt", false, false},
+			},
+		},
+		{
+			name:     "leading slash anchors to the root",
+			patterns: []string{"/build"},
+			cases: []matchCase{
+				{"build", true, true},
+				{"src/build", true, false},
+			},
+		},
+		{
+			name:     "inner slash anchors to the root",
+			patterns: []string{"docs/*.md"},
+			cases: []matchCase{
+				{"docs/readme.md", false, true},
+				{"sub/docs/readme.md", false, false},
+				{"docs/api/readme.md", false, false},
+			},
+		},
+		{
+			name:     "trailing slash matches directories only",
+			patterns: []string{"cache/"},
+			cases: []matchCase{
+				{"cache", true, true},
+				{"cache", false, false},
+				{"src/cache", true, true},
+				{"cache/data.bin", false, true},
+			},
+		},
+		{
+			name:     "leading double star",
+			patterns: []string{"**/tmp"},
+			cases: []matchCase{
+				{"tmp", true, true},
+				{"a/b/tmp", true, true},
+				{"a/tmpx", true, false},
+			},
+		},
+		{
+			name:     "inner double star",
+			patterns: []string{"src/**/gen"},
+			cases: []matchCase{
+				{"src/gen", true, true},
+				{"src/a/b/gen", true, true},
+				{"lib/a/gen", true, false},
+			},
+		},
+		{
+			name:     "trailing double star",
+			patterns: []string{"vendor/**"},
+			cases: []matchCase{
+				{"vendor/a.go", false, true},
+				{"vendor/x/y/z.go", false, true},
+				{"vendor", true, false},
+			},
+		},
+		{
+			name:     "later negation re-includes",
+			patterns: []string{"*.env", "!example.env"},
+			cases: []matchCase{
+				{"prod.env", false, true},
+				{"example.env", false, false},
+			},
+		},
+		{
+			name:     "negation before the rule it overrides has no effect",
+			patterns: []string{"!example.env", "*.env"},
+			cases: []matchCase{
+				{"example.env", false, true},
+			},
+		},
+		{
+			name:     "files in an excluded directory cannot be re-included",
+			patterns: []string{"logs/", "!logs/keep.log"},
+			cases: []matchCase{
+				{"logs/keep.log", false, true},
+			},
+		},
+		{
+			name:     "escaped special characters",
+			patterns: []string{`\#notes`, `\!important`, `file\*`},
+			cases: []matchCase{
+				{"#notes", false, true},
+				{"!important", false, true},
+				{"file*", false, true},
+				{"filex", false, false},
+			},
+		},
+		{
+			name:     "character classes",
+			patterns: []string{"[ab].txt", "[!0-9].dat", "[]x].cfg"},
+			cases: []matchCase{
+				{"a.txt", false, true},
+				{"c.txt", false, false},
+				{"x.dat", false, true},
+				{"5.dat", false, false},
+				{"].cfg", false, true},
+				{"x.cfg", false, true},
+				{"y.cfg", false, false},
+			},
+		},
+		{
+			name:     "question mark does not match a slash",
+			patterns: []string{"a?b"},
+			cases: []matchCase{
+				{"axb", false, true},
+				{"a/b", false, false},
+			},
+		},
+		{
+			name:     "comments and blank lines are ignored",
+			patterns: []string{"# comment", "", "   "},
+			cases: []matchCase{
+				{"# comment", false, false},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher := New(t.TempDir(), Options{})
+			matcher.AddPatterns(test.patterns, "test")
+			if errs := matcher.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected pattern errors: %v", errs)
+			}
+			checkMatches(t, matcher, test.cases)
+		})
+	}
+}
+
+func TestNestedBackupIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, BackupIgnoreFile, "*.tmp\n")
+	writeFile(t, root, "sub/"+BackupIgnoreFile, "!keep.tmp\n/local\n")
+
+	matcher := New(root, Options{})
+	checkMatches(t, matcher, []matchCase{
+		{"a.tmp", false, true},
+		{"sub/a.tmp", false, true},
+		{"sub/keep.tmp", false, false},
+		{"keep.tmp", false, true},
+		{"sub/local", true, true},
+		{"sub/deeper/local", true, false},
+		{"local", true, false},
+	})
+
+	match := matcher.Match("sub/local", true)
+	if match.Rule == nil || match.Rule.Source != "sub/"+BackupIgnoreFile || match.Rule.Line != 2 {
+		t.Errorf("rule for sub/local = %+v, want sub/%s line 2", match.Rule, BackupIgnoreFile)
+	}
+}
+
+func TestGitignoreIsOptIn(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, GitIgnoreFile, "secret.txt\n")
+	writeFile(t, root, BackupIgnoreFile, "!secret.txt\n")
+
+	checkMatches(t, New(root, Options{}), []matchCase{
+		{"secret.txt", false, false},
+	})
+
+	withGit := New(root, Options{UseGitignore: true})
+	checkMatches(t, withGit, []matchCase{
+		{"secret.txt", false, false},
+	})
+
+	writeFile(t, root, BackupIgnoreFile, "")
+	checkMatches(t, New(root, Options{UseGitignore: true}), []matchCase{
+		{"secret.txt", false, true},
+	})
+}
+
+func TestInvalidPatternsAreReported(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "sub/"+BackupIgnoreFile, "ok.txt\n[z-a].txt\n")
+
+	matcher := New(root, Options{})
+	matcher.AddPatterns([]string{"*.log", "[9-0]"}, "project config")
+	matcher.Excluded("sub/file.txt", false)
+
+	errs := matcher.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Error(), "project config:2") {
+		t.Errorf("first error %q does not name project config:2", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), "sub/"+BackupIgnoreFile+":2") {
+		t.Errorf("second error %q does not name sub/%s:2", errs[1], BackupIgnoreFile)
+	}
+
+	checkMatches(t, matcher, []matchCase{
+		{"a.log", false, true},
+		{"sub/ok.txt", false, true},
+	})
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"backup-tool/internal/backup"
@@ -13,13 +14,38 @@ import (
 )
 
 type model struct {
-	backups  []*config.BackupMetadata
-	cursor   int
-	selected map[int]struct{}
-	choice   string
-	quitting bool
+	projectPath string
+	backups     []*config.BackupMetadata
+	cursor      int
+	selected    map[int]struct{}
+	choice      string
+	quitting    bool
+	mode        listMode
+	input       []rune
+	status      string
+	statusError bool
+	busy        bool
 }
 
+type renamedMsg struct {
+	backup *config.BackupMetadata
+	err    error
+}
+
+type deletedMsg struct {
+	backup *config.BackupMetadata
+	freed  int64
+	err    error
+}
+
+type listMode int
+
+const (
+	modeBrowse listMode = iota
+	modeRename
+	modeConfirmDelete
+)
+
 type action int
 
 const (
@@ -54,16 +80,22 @@ var (
 
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#626262"))
+
+	inputStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			Foreground(lipgloss.Color("#FFD93D")).
+			Bold(true)
 )
 
-func initialModel(backups []*config.BackupMetadata) model {
+func initialModel(projectPath string, backups []*config.BackupMetadata) model {
 	sort.Slice(backups, func(i, j int) bool {
 		return backups[i].CreatedAt.After(backups[j].CreatedAt)
 	})
 
 	return model{
-		backups:  backups,
-		selected: make(map[int]struct{}),
+		projectPath: projectPath,
+		backups:     backups,
+		selected:    make(map[int]struct{}),
 	}
 }
 
@@ -73,46 +105,166 @@ func (m model) Init() tea.Cmd {
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			m.quitting = true
-			m.choice = "quit"
+	case renamedMsg:
+		return m.finishRename(msg), nil
+	case deletedMsg:
+		return m.finishDelete(msg), nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if keyMsg.String() == "ctrl+c" {
+		m.quitting = true
+		m.choice = "quit"
+		return m, tea.Quit
+	}
+
+	if m.busy {
+		return m, nil
+	}
+
+	switch m.mode {
+	case modeRename:
+		return m.updateRename(keyMsg)
+	case modeConfirmDelete:
+		return m.updateConfirmDelete(keyMsg)
+	}
+
+	switch keyMsg.String() {
+	case "q":
+		m.quitting = true
+		m.choice = "quit"
+		return m, tea.Quit
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.backups)-1 {
+			m.cursor++
+		}
+
+	case "enter":
+		if len(m.backups) > 0 {
+			m.choice = fmt.Sprintf("load:%s", m.backups[m.cursor].ID)
 			return m, tea.Quit
+		}
 
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
+	case "r":
+		if len(m.backups) > 0 {
+			m.mode = modeRename
+			m.input = []rune(m.backups[m.cursor].Name)
+			m.status = ""
+		}
 
-		case "down", "j":
-			if m.cursor < len(m.backups)-1 {
-				m.cursor++
-			}
+	case "d":
+		if len(m.backups) > 0 {
+			m.mode = modeConfirmDelete
+			m.status = ""
+		}
+	}
 
-		case "enter":
-			if len(m.backups) > 0 {
-				m.choice = fmt.Sprintf("load:%d", m.cursor)
-				return m, tea.Quit
-			}
+	return m, nil
+}
 
-		case "r":
-			if len(m.backups) > 0 {
-				m.choice = fmt.Sprintf("rename:%d", m.cursor)
-				return m, tea.Quit
-			}
+func (m model) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeBrowse
+
+	case tea.KeyEnter:
+		m.mode = modeBrowse
+		m.busy = true
+		m.setStatus("Renaming backup...", false)
+		projectPath, id, name := m.projectPath, m.backups[m.cursor].ID, strings.TrimSpace(string(m.input))
+		return m, func() tea.Msg {
+			renamed, err := backup.RenameBackup(projectPath, id, name)
+			return renamedMsg{backup: renamed, err: err}
+		}
 
-		case "d":
-			if len(m.backups) > 0 {
-				m.choice = fmt.Sprintf("delete:%d", m.cursor)
-				return m, tea.Quit
-			}
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
 		}
+
+	case tea.KeySpace:
+		m.input = append(m.input, ' ')
+
+	case tea.KeyRunes:
+		m.input = append(m.input, msg.Runes...)
 	}
 
 	return m, nil
 }
 
+func (m model) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+
+	if msg.String() != "y" && msg.String() != "Y" {
+		m.setStatus("Delete cancelled", false)
+		return m, nil
+	}
+
+	target := m.backups[m.cursor]
+	m.busy = true
+	m.setStatus(fmt.Sprintf("Deleting '%s'...", displayName(target)), false)
+	projectPath := m.projectPath
+	return m, func() tea.Msg {
+		freed, err := backup.DeleteBackups(projectPath, []string{target.ID})
+		return deletedMsg{backup: target, freed: freed, err: err}
+	}
+}
+
+func (m model) finishRename(msg renamedMsg) model {
+	m.busy = false
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Failed to rename backup: %v", msg.err), true)
+		return m
+	}
+
+	if i := m.indexOf(msg.backup.ID); i >= 0 {
+		m.backups[i] = msg.backup
+	}
+	m.setStatus("Backup renamed", false)
+	return m
+}
+
+func (m model) finishDelete(msg deletedMsg) model {
+	m.busy = false
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Failed to delete backup: %v", msg.err), true)
+		return m
+	}
+
+	if i := m.indexOf(msg.backup.ID); i >= 0 {
+		m.backups = append(m.backups[:i:i], m.backups[i+1:]...)
+	}
+	if m.cursor >= len(m.backups) && m.cursor > 0 {
+		m.cursor--
+	}
+	m.setStatus(fmt.Sprintf("Backup '%s' deleted, %s freed", displayName(msg.backup), FormatSize(msg.freed)), false)
+	return m
+}
+
+func (m model) indexOf(id string) int {
+	for i, b := range m.backups {
+		if b.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *model) setStatus(text string, isError bool) {
+	m.status = text
+	m.statusError = isError
+}
+
 func (m model) View() string {
 	if m.quitting {
 		return quitTextStyle.Render("Exiting...")
@@ -131,16 +283,25 @@ func (m model) View() string {
 			cursor = ">"
 		}
 
-		displayName := backup.Name
-		if displayName == "" {
-			displayName = backup.CreatedAt.Format("2006-01-02 15:04:05")
+		name := displayName(backup)
+		for _, tag := range backup.Tags {
+			name += " [" + tag + "]"
+		}
+		if backup.Encrypted {
+			name += " [encrypted]"
+		}
+
+		if m.cursor == i && m.mode == modeRename {
+			s += inputStyle.Render(fmt.Sprintf("> Rename: %s█", string(m.input))) + "\n"
+			continue
 		}
 
-		size := formatSize(backup.Size)
+		size := FormatSize(backup.UncompressedSize)
+		added := "+" + FormatSize(backup.AddedSize)
 		age := formatAge(backup.CreatedAt)
 
-		line := fmt.Sprintf("%s %-30s | %-8s | %s",
-			cursor, displayName, size, age)
+		line := fmt.Sprintf("%s %-30s | %-8s | %-9s | %s",
+			cursor, name, size, added, age)
 
 		if m.cursor == i {
 			s += selectedItemStyle.Render(line) + "\n"
@@ -150,12 +311,34 @@ func (m model) View() string {
 	}
 
 	s += "\n"
-	s += helpStyle.Render("↑/↓: navigate • Enter: load • r: rename • d: delete • q: quit")
+
+	switch m.mode {
+	case modeRename:
+		s += helpStyle.Render("Enter: save • Esc: cancel")
+	case modeConfirmDelete:
+		s += inputStyle.Render(fmt.Sprintf("Delete '%s'? (y/N)", displayName(m.backups[m.cursor])))
+	default:
+		if m.status != "" {
+			if m.statusError {
+				s += Error(m.status) + "\n"
+			} else {
+				s += Success(m.status) + "\n"
+			}
+		}
+		s += helpStyle.Render("↑/↓: navigate • Enter: load • r: rename • d: delete • q: quit")
+	}
 
 	return s
 }
 
-func formatSize(bytes int64) string {
+func displayName(b *config.BackupMetadata) string {
+	if b.Name != "" {
+		return b.Name
+	}
+	return b.CreatedAt.Format("2006-01-02 15:04:05")
+}
+
+func FormatSize(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -196,7 +379,11 @@ func RunListUI(projectPath string) (string, error) {
 		return "", fmt.Errorf("failed to load backup list: %v", err)
 	}
 
-	p := tea.NewProgram(initialModel(backups))
+	if err := backup.UnlockBackups(projectPath, backups); err != nil {
+		return "", fmt.Errorf("failed to unlock encrypted backups: %v", err)
+	}
+
+	p := tea.NewProgram(initialModel(projectPath, backups))
 	m, err := p.Run()
 	if err != nil {
 		return "", fmt.Errorf("UI error: %v", err)
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 var (
@@ -77,3 +78,19 @@ func Hint(text string) string {
 func Progress(text string) string {
 	return ProgressStyle.Render("[PROGRESS] " + text)
 }
+
+func Added(text string) string {
+	return SuccessStyle.Render(text)
+}
+
+func Removed(text string) string {
+	return ErrorStyle.Render(text)
+}
+
+func Modified(text string) string {
+	return WarningStyle.Render(text)
+}
+
+func DisableColor() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
@@ -0,0 +1,137 @@
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+type pollSource struct {
+	root     string
+	filter   *filter
+	interval time.Duration
+	events   chan string
+	errors   chan error
+	done     chan struct{}
+}
+
+func newPollSource(root string, filter *filter, interval time.Duration) (*pollSource, error) {
+	s := &pollSource{
+		root:     root,
+		filter:   filter,
+		interval: interval,
+		events:   make(chan string),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+
+	stamps, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	go s.loop(stamps)
+	return s, nil
+}
+
+func (s *pollSource) Events() <-chan string {
+	return s.events
+}
+
+func (s *pollSource) Errors() <-chan error {
+	return s.errors
+}
+
+func (s *pollSource) Close() error {
+	close(s.done)
+	return nil
+}
+
+func (s *pollSource) loop(previous map[string]fileStamp) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		current, err := s.scan()
+		if err != nil {
+			s.errors <- err
+			return
+		}
+
+		for relPath, stamp := range current {
+			if old, ok := previous[relPath]; !ok || old != stamp {
+				if !s.send(relPath) {
+					return
+				}
+			}
+		}
+		for relPath := range previous {
+			if _, ok := current[relPath]; !ok {
+				if !s.send(relPath) {
+					return
+				}
+			}
+		}
+		previous = current
+	}
+}
+
+func (s *pollSource) send(relPath string) bool {
+	select {
+	case s.events <- relPath:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *pollSource) scan() (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+
+	err := filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		relPath, ok := s.filter.relPath(path)
+		if !ok {
+			if path != s.root && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if s.filter.excluded(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		stamp := fileStamp{modTime: info.ModTime(), mode: info.Mode()}
+		if !info.IsDir() {
+			stamp.size = info.Size()
+		}
+		stamps[relPath] = stamp
+		return nil
+	})
+
+	return stamps, err
+}
@@ -0,0 +1,156 @@
+//go:build linux
+
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+type notifySource struct {
+	watcher *fsnotify.Watcher
+	root    string
+	filter  *filter
+	events  chan string
+	errors  chan error
+	done    chan struct{}
+}
+
+func newSource(root string, filter *filter, pollInterval time.Duration) (source, string, error) {
+	source, err := newNotifySource(root, filter)
+	if err == nil {
+		return source, MethodNotify, nil
+	}
+
+	poll, err := newPollSource(root, filter, pollInterval)
+	if err != nil {
+		return nil, "", err
+	}
+	return poll, MethodPoll, nil
+}
+
+func newNotifySource(root string, filter *filter) (*notifySource, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &notifySource{
+		watcher: watcher,
+		root:    root,
+		filter:  filter,
+		events:  make(chan string),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+
+	if err := s.addTree(root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go s.loop()
+	return s, nil
+}
+
+func (s *notifySource) Events() <-chan string {
+	return s.events
+}
+
+func (s *notifySource) Errors() <-chan error {
+	return s.errors
+}
+
+func (s *notifySource) Close() error {
+	close(s.done)
+	return s.watcher.Close()
+}
+
+func (s *notifySource) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != s.root {
+			relPath, ok := s.filter.relPath(path)
+			if !ok || s.filter.excluded(relPath, true) {
+				return filepath.SkipDir
+			}
+		}
+
+		return s.watcher.Add(path)
+	})
+}
+
+func (s *notifySource) loop() {
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+
+			relPath, excluded := s.filter.excludedPath(event.Name)
+			if excluded {
+				continue
+			}
+
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+					if err := s.addTree(event.Name); err != nil {
+						s.fail(err)
+						return
+					}
+				}
+			}
+
+			if !s.send(relPath) {
+				return
+			}
+
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				if !s.send(".") {
+					return
+				}
+				continue
+			}
+			s.fail(err)
+			return
+		}
+	}
+}
+
+func (s *notifySource) send(relPath string) bool {
+	select {
+	case s.events <- relPath:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *notifySource) fail(err error) {
+	select {
+	case s.errors <- err:
+	default:
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package watch
+
+import "time"
+
+func newSource(root string, filter *filter, pollInterval time.Duration) (source, string, error) {
+	source, err := newPollSource(root, filter, pollInterval)
+	if err != nil {
+		return nil, "", err
+	}
+	return source, MethodPoll, nil
+}
@@ -0,0 +1,167 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backup-tool/internal/ignore"
+)
+
+const (
+	MethodNotify = "fsnotify"
+	MethodPoll   = "polling"
+
+	DefaultPollInterval = 2 * time.Second
+)
+
+type Options struct {
+	Quiet        time.Duration
+	Interval     time.Duration
+	PollInterval time.Duration
+	Poll         bool
+	Ignore       []string
+}
+
+type source interface {
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
+
+type Watcher struct {
+	root    string
+	options Options
+	method  string
+	source  source
+}
+
+func New(root string, matcher *ignore.Matcher, options Options) (*Watcher, error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+
+	filter := &filter{root: root, matcher: matcher}
+	for _, path := range options.Ignore {
+		if relPath, err := filepath.Rel(root, path); err == nil && relPath != "." && !strings.HasPrefix(relPath, "..") {
+			filter.ignored = append(filter.ignored, filepath.ToSlash(relPath))
+		}
+	}
+
+	var source source
+	var method string
+	var err error
+	if options.Poll {
+		source, err = newPollSource(root, filter, options.PollInterval)
+		method = MethodPoll
+	} else {
+		source, method, err = newSource(root, filter, options.PollInterval)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{root: root, options: options, method: method, source: source}, nil
+}
+
+func (w *Watcher) Method() string {
+	return w.method
+}
+
+func (w *Watcher) Close() error {
+	return w.source.Close()
+}
+
+func (w *Watcher) Run(ctx context.Context, backup func(ctx context.Context, changes []string) error) error {
+	pending := make(map[string]bool)
+
+	quiet := time.NewTimer(time.Hour)
+	quiet.Stop()
+	deadline := time.NewTimer(time.Hour)
+	deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-w.source.Errors():
+			return err
+
+		case relPath := <-w.source.Events():
+			if len(pending) == 0 && w.options.Interval > 0 {
+				deadline.Reset(w.options.Interval)
+			}
+			pending[relPath] = true
+			quiet.Reset(w.options.Quiet)
+			continue
+
+		case <-quiet.C:
+		case <-deadline.C:
+		}
+
+		if len(pending) == 0 {
+			continue
+		}
+
+		quiet.Stop()
+		deadline.Stop()
+
+		changes := make([]string, 0, len(pending))
+		for relPath := range pending {
+			changes = append(changes, relPath)
+		}
+		sort.Strings(changes)
+
+		if err := backup(ctx, changes); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			quiet.Reset(w.options.Quiet)
+			continue
+		}
+
+		pending = make(map[string]bool)
+	}
+}
+
+type filter struct {
+	root    string
+	matcher *ignore.Matcher
+	ignored []string
+}
+
+func (f *filter) relPath(path string) (string, bool) {
+	relPath, err := filepath.Rel(f.root, path)
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+		return "", false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	for _, ignored := range f.ignored {
+		if relPath == ignored || strings.HasPrefix(relPath, ignored+"/") {
+			return "", false
+		}
+	}
+	return relPath, true
+}
+
+func (f *filter) excluded(relPath string, isDir bool) bool {
+	return f.matcher.Excluded(relPath, isDir)
+}
+
+func (f *filter) excludedPath(path string) (string, bool) {
+	relPath, ok := f.relPath(path)
+	if !ok {
+		return "", true
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return relPath, f.excluded(relPath, false) || f.excluded(relPath, true)
+	}
+	return relPath, f.excluded(relPath, info.IsDir())
+}